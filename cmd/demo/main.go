@@ -10,7 +10,8 @@ import (
 )
 
 func main() {
-	fmt.Println("=== reddit-kv Demo (using mock backend) ===\n")
+	fmt.Println("=== reddit-kv Demo (using mock backend) ===")
+	fmt.Println()
 
 	// Create a mock Reddit API and client
 	mock := redditkv.NewMockRedditAPI()
@@ -2,8 +2,6 @@ package cli
 
 import (
 	"fmt"
-	"strconv"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/sprite/reddit-kv/pkg/redditkv"
@@ -17,45 +15,54 @@ var appendCmd = &cobra.Command{
 By default, appends as a new top-level comment (sibling to root).
 Use --parent to specify a path to append as a child of a specific node.
 
-Path format: comma-separated indices (e.g., "0,1" means second child of first child)`,
+Path format: comma-separated indices (e.g., "0,1" means second child of first child)
+
+Reddit archives posts after six months. Use --auto-migrate to repost an
+archived key to a fresh post before appending.`,
 	Args: cobra.ExactArgs(2),
 	RunE: runAppend,
 }
 
-var flagParent string
+var (
+	flagParent      string
+	flagAutoMigrate bool
+)
 
 func init() {
 	appendCmd.Flags().StringVar(&flagParent, "parent", "", "Path to parent node (e.g., '0,1')")
+	appendCmd.Flags().BoolVar(&flagAutoMigrate, "auto-migrate", false, "Repost archived keys to a fresh post before appending")
 }
 
 func runAppend(cmd *cobra.Command, args []string) error {
 	key := args[0]
 	value := args[1]
 
-	cfg, err := redditkv.LoadConfig()
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	client, err := redditkv.New(*cfg)
+	var opts []redditkv.Option
+	if flagAutoMigrate {
+		opts = append(opts, redditkv.WithAutoMigrate())
+	}
+
+	client, err := newClient(cfg, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
-	var parentPath []int
-	if flagParent != "" {
-		parts := strings.Split(flagParent, ",")
-		parentPath = make([]int, len(parts))
-		for i, part := range parts {
-			idx, err := strconv.Atoi(strings.TrimSpace(part))
-			if err != nil {
-				return fmt.Errorf("invalid path: %s", flagParent)
-			}
-			parentPath[i] = idx
-		}
+	store, err := storeFor(cfg, client)
+	if err != nil {
+		return err
+	}
+
+	parentPath, err := redditkv.ParsePath(flagParent)
+	if err != nil {
+		return err
 	}
 
-	if err := client.Append(key, value, parentPath); err != nil {
+	if err := store.Append(key, value, parentPath); err != nil {
 		return fmt.Errorf("failed to append: %w", err)
 	}
 
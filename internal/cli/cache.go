@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the on-disk cache",
+	Long: `Commands share an on-disk cache of keys' post IDs and recent values,
+so repeated lookups don't search Reddit every time. Values are reused for
+a few seconds; writes made with reddit-kv invalidate them immediately.`,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete the on-disk cache",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheClear,
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	if err := redditkv.ClearCache(); err != nil {
+		return err
+	}
+
+	fmt.Printf("OK\n")
+	return nil
+}
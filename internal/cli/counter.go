@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var incrCmd = &cobra.Command{
+	Use:   "incr <key> [amount]",
+	Short: "Increment a counter",
+	Long: `Add amount (default 1) to a counter and print its new total. The
+counter is created if it doesn't exist.
+
+Each increment appends a delta comment, so concurrent increments aren't
+lost. 'get' prints a counter's total.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runIncr,
+}
+
+var decrCmd = &cobra.Command{
+	Use:   "decr <key> [amount]",
+	Short: "Decrement a counter",
+	Long:  `Subtract amount (default 1) from a counter and print its new total.`,
+	Args:  cobra.RangeArgs(1, 2),
+	RunE:  runIncr,
+}
+
+func runIncr(cmd *cobra.Command, args []string) error {
+	amount := int64(1)
+	if len(args) == 2 {
+		n, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid amount: %s", args[1])
+		}
+		amount = n
+	}
+	if cmd.Name() == "decr" {
+		amount = -amount
+	}
+
+	client, err := newKVWriter(cmd)
+	if err != nil {
+		return err
+	}
+
+	total, err := client.Incr(args[0], amount)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(total)
+	return nil
+}
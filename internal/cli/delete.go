@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/sprite/reddit-kv/pkg/redditkv"
 )
 
 var deleteCmd = &cobra.Command{
@@ -18,17 +17,22 @@ var deleteCmd = &cobra.Command{
 func runDelete(cmd *cobra.Command, args []string) error {
 	key := args[0]
 
-	cfg, err := redditkv.LoadConfig()
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	client, err := redditkv.New(*cfg)
+	client, err := newClient(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
-	if err := client.Delete(key); err != nil {
+	store, err := storeFor(cfg, client)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Delete(key); err != nil {
 		return fmt.Errorf("failed to delete key: %w", err)
 	}
 
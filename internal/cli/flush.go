@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+var flushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Send queued writes to Reddit",
+	Long: `Send the writes queued in write-behind mode to Reddit, in order.
+
+Writes that fail with a temporary error are retried; if they keep failing,
+the flush stops and they stay queued for the next one. Writes Reddit
+rejects outright, such as appends to a missing key, are dropped and
+listed.
+
+Conflict policies, for keys overwritten on Reddit after a write was queued:
+  local-wins   apply the queued write anyway (default)
+  remote-wins  drop the queued write and keep Reddit's value
+
+Use --every to keep flushing at an interval until interrupted.`,
+	Args: cobra.NoArgs,
+	RunE: runFlush,
+}
+
+var (
+	flagFlushPolicy string
+	flagFlushEvery  time.Duration
+)
+
+func init() {
+	flushCmd.Flags().StringVar(&flagFlushPolicy, "policy", string(redditkv.LocalWins), "Conflict policy: local-wins or remote-wins")
+	flushCmd.Flags().DurationVar(&flagFlushEvery, "every", 0, "Flush repeatedly at this interval")
+}
+
+func runFlush(cmd *cobra.Command, args []string) error {
+	policy := redditkv.ConflictPolicy(flagFlushPolicy)
+	if policy != redditkv.LocalWins && policy != redditkv.RemoteWins {
+		return fmt.Errorf("unknown conflict policy: %s", flagFlushPolicy)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	wb, err := newWriteBehind(cfg, client, policy)
+	if err != nil {
+		return err
+	}
+
+	if flagFlushEvery > 0 {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		wb.FlushLoop(ctx, flagFlushEvery, func(report *redditkv.FlushReport, err error) {
+			printFlushReport(report)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		})
+		return nil
+	}
+
+	report, err := wb.Flush()
+	printFlushReport(report)
+	return err
+}
+
+func printFlushReport(report *redditkv.FlushReport) {
+	if report == nil {
+		return
+	}
+	for _, qw := range report.Conflicts {
+		fmt.Printf("conflict: dropped %s of %s\n", qw.Op, qw.Key)
+	}
+	for _, fw := range report.Failed {
+		fmt.Printf("rejected: dropped %s of %s: %v\n", fw.Op, fw.Key, fw.Err)
+	}
+	fmt.Printf("applied %d, %d still queued\n", report.Applied, report.Remaining)
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Check the store for duplicate and broken keys",
+	Long: `Check the store for consistency problems:
+
+- duplicate keys (several posts with the same title)
+- posts without a value comment
+- posts that can't be found by searching for their key
+
+Use --repair to delete all but one post of each duplicated key.
+--keep chooses which copy survives: newest, oldest or most-comments.`,
+	Args: cobra.NoArgs,
+	RunE: runFsck,
+}
+
+var (
+	flagRepair   bool
+	flagKeep     string
+	flagFsckJSON bool
+)
+
+func init() {
+	fsckCmd.Flags().BoolVar(&flagRepair, "repair", false, "Delete duplicate posts")
+	fsckCmd.Flags().StringVar(&flagKeep, "keep", "newest", "Which duplicate to keep: newest, oldest or most-comments")
+	fsckCmd.Flags().BoolVar(&flagFsckJSON, "json", false, "Output report as JSON")
+}
+
+func runFsck(cmd *cobra.Command, args []string) error {
+	policy, err := redditkv.ParseRepairPolicy(flagKeep)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	var report *redditkv.CheckReport
+	if flagRepair {
+		report, err = client.Repair(policy)
+	} else {
+		report, err = client.Check()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check store: %w", err)
+	}
+
+	if flagFsckJSON {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	for _, dup := range report.Duplicates {
+		fmt.Printf("duplicate: %s (%d posts)\n", dup.Key, len(dup.PostIDs))
+	}
+	for _, ref := range report.Empty {
+		fmt.Printf("no value: %s (post %s)\n", ref.Key, ref.PostID)
+	}
+	for _, ref := range report.Unreachable {
+		fmt.Printf("unreachable: %s (post %s)\n", ref.Key, ref.PostID)
+	}
+	for _, ref := range report.Deleted {
+		fmt.Printf("deleted: %s (post %s)\n", ref.Key, ref.PostID)
+	}
+
+	if report.OK() {
+		fmt.Println("OK")
+	}
+	return nil
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/sprite/reddit-kv/pkg/redditkv"
 )
 
 var getCmd = &cobra.Command{
@@ -18,30 +17,44 @@ Returns the value as a JSON tree structure representing the comment hierarchy.`,
 	RunE: runGet,
 }
 
-var flagRaw bool
+var (
+	flagRaw     bool
+	flagVersion bool
+)
 
 func init() {
 	getCmd.Flags().BoolVar(&flagRaw, "raw", false, "Output raw value (only works for scalar values)")
+	getCmd.Flags().BoolVar(&flagVersion, "version", false, "Output only the key's version token")
 }
 
 func runGet(cmd *cobra.Command, args []string) error {
 	key := args[0]
 
-	cfg, err := redditkv.LoadConfig()
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	client, err := redditkv.New(*cfg)
+	client, err := newClient(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
-	value, err := client.Get(key)
+	store, err := storeFor(cfg, client)
+	if err != nil {
+		return err
+	}
+
+	value, err := store.Get(key)
 	if err != nil {
 		return fmt.Errorf("failed to get key: %w", err)
 	}
 
+	if flagVersion {
+		fmt.Println(value.Version)
+		return nil
+	}
+
 	if flagRaw {
 		// Output just the root value
 		fmt.Println(value.Value)
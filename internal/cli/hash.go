@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+var hsetCmd = &cobra.Command{
+	Use:   "hset <key> <field> <value>",
+	Short: "Set a field in a hash",
+	Long: `Set a field in a hash, creating the hash if needed. Each field is a
+top-level comment reading "field: value"; setting an existing field edits
+its comment.`,
+	Args: cobra.ExactArgs(3),
+	RunE: runHSet,
+}
+
+var hgetCmd = &cobra.Command{
+	Use:   "hget <key> <field>",
+	Short: "Get a field from a hash",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runHGet,
+}
+
+var hdelCmd = &cobra.Command{
+	Use:   "hdel <key> <field> [field...]",
+	Short: "Delete fields from a hash",
+	Long:  `Delete fields from a hash and print how many of them existed.`,
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runHDel,
+}
+
+var hgetallCmd = &cobra.Command{
+	Use:   "hgetall <key>",
+	Short: "Print every field of a hash as JSON",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runHGetAll,
+}
+
+var hkeysCmd = &cobra.Command{
+	Use:   "hkeys <key>",
+	Short: "List the fields of a hash",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runHKeys,
+}
+
+func runHSet(cmd *cobra.Command, args []string) error {
+	client, err := newKVWriter(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := client.HSet(args[0], args[1], args[2]); err != nil {
+		return err
+	}
+
+	fmt.Printf("OK\n")
+	return nil
+}
+
+func runHGet(cmd *cobra.Command, args []string) error {
+	client, err := newKVClient()
+	if err != nil {
+		return err
+	}
+
+	value, err := client.HGet(args[0], args[1])
+	if err != nil {
+		var notFound *redditkv.KeyNotFoundError
+		var fieldNotFound *redditkv.FieldNotFoundError
+		if errors.As(err, &notFound) || errors.As(err, &fieldNotFound) {
+			fmt.Println("(nil)")
+			return nil
+		}
+		return err
+	}
+
+	fmt.Println(value)
+	return nil
+}
+
+func runHDel(cmd *cobra.Command, args []string) error {
+	client, err := newKVWriter(cmd)
+	if err != nil {
+		return err
+	}
+
+	removed, err := client.HDel(args[0], args[1:]...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(removed)
+	return nil
+}
+
+func runHGetAll(cmd *cobra.Command, args []string) error {
+	client, err := newKVClient()
+	if err != nil {
+		return err
+	}
+
+	fields, err := client.HGetAll(args[0])
+	if err != nil {
+		return err
+	}
+
+	output, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fields: %w", err)
+	}
+	fmt.Println(string(output))
+	return nil
+}
+
+func runHKeys(cmd *cobra.Command, args []string) error {
+	client, err := newKVClient()
+	if err != nil {
+		return err
+	}
+
+	fields, err := client.HKeys(args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		fmt.Println(field)
+	}
+	return nil
+}
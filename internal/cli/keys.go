@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/sprite/reddit-kv/pkg/redditkv"
 )
 
 var keysCmd = &cobra.Command{
@@ -23,17 +22,22 @@ func init() {
 }
 
 func runKeys(cmd *cobra.Command, args []string) error {
-	cfg, err := redditkv.LoadConfig()
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	client, err := redditkv.New(*cfg)
+	client, err := newClient(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
-	keys, err := client.Keys()
+	store, err := storeFor(cfg, client)
+	if err != nil {
+		return err
+	}
+
+	keys, err := store.Keys()
 	if err != nil {
 		return fmt.Errorf("failed to list keys: %w", err)
 	}
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+// lockPollInterval is how often --wait retries a held lock.
+const lockPollInterval = 5 * time.Second
+
+// minLockTTL is the shortest --ttl accepted. run-locked refreshes the lease
+// every third of it, and each refresh is a round trip to Reddit.
+const minLockTTL = 10 * time.Second
+
+var lockCmd = &cobra.Command{
+	Use:   "lock <name>",
+	Short: "Acquire a distributed lock",
+	Long: `Acquire a named lock shared by everyone using the subreddit.
+
+Prints the lease ID and fencing token. Pass the lease ID to 'unlock' to
+release it. The lease expires on its own after --ttl.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLock,
+}
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock <name> <lease-id>",
+	Short: "Release a distributed lock",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runUnlock,
+}
+
+var runLockedCmd = &cobra.Command{
+	Use:   "run-locked <name> -- <command> [args...]",
+	Short: "Run a command while holding a lock",
+	Long: `Acquire a named lock, run a command, and release the lock when it exits.
+
+The lease is refreshed in the background while the command runs. If it
+can't be refreshed, the command is killed. The fencing token is passed to
+the command in the REDDIT_KV_FENCING_TOKEN environment variable.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runRunLocked,
+}
+
+var (
+	flagLockTTL  time.Duration
+	flagLockWait time.Duration
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{lockCmd, runLockedCmd} {
+		cmd.Flags().DurationVar(&flagLockTTL, "ttl", time.Minute, "Lease duration (at least 10s)")
+		cmd.Flags().DurationVar(&flagLockWait, "wait", 0, "How long to keep retrying if the lock is held")
+	}
+}
+
+func runLock(cmd *cobra.Command, args []string) error {
+	if err := checkLockTTL(); err != nil {
+		return err
+	}
+
+	client, err := newKVClient()
+	if err != nil {
+		return err
+	}
+
+	lease, err := acquireLease(client, args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %d\n", lease.ID, lease.Token)
+	return nil
+}
+
+// checkLockTTL rejects a --ttl too short to hold and refresh a lease.
+func checkLockTTL() error {
+	if flagLockTTL < minLockTTL {
+		return fmt.Errorf("--ttl must be at least %s", minLockTTL)
+	}
+	return nil
+}
+
+func runUnlock(cmd *cobra.Command, args []string) error {
+	client, err := newKVClient()
+	if err != nil {
+		return err
+	}
+
+	lease, err := client.ResumeLease(args[0], args[1])
+	if err != nil {
+		return fmt.Errorf("failed to find lease: %w", err)
+	}
+	if err := lease.Unlock(); err != nil {
+		return err
+	}
+
+	fmt.Printf("OK\n")
+	return nil
+}
+
+func runRunLocked(cmd *cobra.Command, args []string) error {
+	if err := checkLockTTL(); err != nil {
+		return err
+	}
+
+	client, err := newKVClient()
+	if err != nil {
+		return err
+	}
+
+	lease, err := acquireLease(client, args[0])
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	refreshed := make(chan struct{})
+	go func() {
+		defer close(refreshed)
+		ticker := time.NewTicker(flagLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := lease.Refresh(flagLockTTL); err != nil {
+					fmt.Fprintf(os.Stderr, "reddit-kv: lost lock %s: %v\n", lease.Name, err)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	child := exec.CommandContext(ctx, args[1], args[2:]...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	child.Env = append(os.Environ(), fmt.Sprintf("REDDIT_KV_FENCING_TOKEN=%d", lease.Token))
+	runErr := child.Run()
+
+	cancel()
+	<-refreshed
+	if err := lease.Unlock(); err != nil {
+		fmt.Fprintf(os.Stderr, "reddit-kv: %v\n", err)
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	return runErr
+}
+
+// acquireLease takes the lock, polling until --wait elapses if it's held.
+func acquireLease(client *redditkv.KVClient, name string) (*redditkv.Lease, error) {
+	deadline := time.Now().Add(flagLockWait)
+	for {
+		lease, err := client.Lock(name, flagLockTTL)
+		if err == nil {
+			return lease, nil
+		}
+
+		var held *redditkv.LockHeldError
+		if !errors.As(err, &held) || time.Now().Add(lockPollInterval).After(deadline) {
+			return nil, fmt.Errorf("failed to acquire lock: %w", err)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/spf13/cobra"
+	"github.com/sprite/reddit-kv/internal/server"
+)
+
+var memcachedCmd = &cobra.Command{
+	Use:   "memcached",
+	Short: "Serve the key-value API over the memcached text protocol",
+	Long: `Serve the memcached text protocol so existing memcached clients can use
+the subreddit as a backend.
+
+Supported commands: get, gets, set, add, replace, append, cas, delete,
+version and quit. Flags and expiration times are stored as key metadata;
+expired keys read as missing and are deleted lazily.`,
+	Args: cobra.NoArgs,
+	RunE: runMemcached,
+}
+
+var flagMemcachedListen string
+
+func init() {
+	memcachedCmd.Flags().StringVar(&flagMemcachedListen, "listen", ":11211", "Address to listen on")
+}
+
+func runMemcached(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := newServerClient(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", flagMemcachedListen)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	fmt.Printf("Listening on %s\n", flagMemcachedListen)
+	return server.NewMemcachedServer(client).Serve(listener)
+}
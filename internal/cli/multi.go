@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+var mgetCmd = &cobra.Command{
+	Use:   "mget <key>...",
+	Short: "Get the values for several keys",
+	Long: `Get the value trees for several keys at once.
+
+Prints a JSON array with one entry per key, in order. Missing keys have a
+null value; keys that failed have an error message instead.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runMGet,
+}
+
+var msetCmd = &cobra.Command{
+	Use:   "mset <key> <value> [<key> <value>...]",
+	Short: "Set several keys",
+	Long:  `Set several keys to values at once, as 'set' does for one key.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 || len(args)%2 != 0 {
+			return fmt.Errorf("expected key/value pairs, got %d arguments", len(args))
+		}
+		return nil
+	},
+	RunE: runMSet,
+}
+
+var mdelCmd = &cobra.Command{
+	Use:   "mdel <key>...",
+	Short: "Delete several keys",
+	Long:  `Delete several keys at once, as 'delete' does for one key.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runMDel,
+}
+
+var (
+	flagMGetRaw     bool
+	flagConcurrency int
+)
+
+func init() {
+	mgetCmd.Flags().BoolVar(&flagMGetRaw, "raw", false, "Output one raw root value per line, (nil) for missing keys")
+	for _, cmd := range []*cobra.Command{mgetCmd, msetCmd, mdelCmd} {
+		cmd.Flags().IntVar(&flagConcurrency, "concurrency", 4, "Number of keys to work on at once")
+	}
+}
+
+// mgetEntry is one key in mget's JSON output.
+type mgetEntry struct {
+	Key   string              `json:"key"`
+	Value *redditkv.ValueNode `json:"value"`
+	Error string              `json:"error,omitempty"`
+}
+
+func runMGet(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.WriteBehind {
+		return errWriteBehind(cmd.Name())
+	}
+
+	client, err := newClient(cfg, redditkv.WithConcurrency(flagConcurrency))
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	results := client.MGet(args)
+
+	failed := 0
+	entries := make([]mgetEntry, len(results))
+	for i, res := range results {
+		entries[i] = mgetEntry{Key: res.Key, Value: res.Value}
+		var notFound *redditkv.KeyNotFoundError
+		if res.Err != nil && !errors.As(res.Err, &notFound) {
+			entries[i].Error = res.Err.Error()
+			failed++
+		}
+	}
+
+	if flagMGetRaw {
+		for _, entry := range entries {
+			switch {
+			case entry.Error != "":
+				fmt.Fprintf(os.Stderr, "%s: %s\n", entry.Key, entry.Error)
+				fmt.Println("(nil)")
+			case entry.Value == nil:
+				fmt.Println("(nil)")
+			default:
+				fmt.Println(entry.Value.Value)
+			}
+		}
+	} else {
+		output, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal values: %w", err)
+		}
+		fmt.Println(string(output))
+	}
+
+	return batchError(failed, len(results))
+}
+
+func runMSet(cmd *cobra.Command, args []string) error {
+	values := make(map[string]string)
+	for i := 0; i < len(args); i += 2 {
+		values[args[i]] = args[i+1]
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.WriteBehind {
+		return errWriteBehind(cmd.Name())
+	}
+
+	client, err := newClient(cfg, redditkv.WithConcurrency(flagConcurrency))
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	return printResults(client.MSet(values))
+}
+
+func runMDel(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.WriteBehind {
+		return errWriteBehind(cmd.Name())
+	}
+
+	client, err := newClient(cfg, redditkv.WithConcurrency(flagConcurrency))
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	return printResults(client.MDelete(args))
+}
+
+// printResults prints "key: OK" or the key's error for each result of a
+// multi-key write.
+func printResults(results []redditkv.KeyResult) error {
+	failed := 0
+	for _, res := range results {
+		if res.Err != nil {
+			fmt.Printf("%s: %v\n", res.Key, res.Err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: OK\n", res.Key)
+	}
+	return batchError(failed, len(results))
+}
+
+func batchError(failed, total int) error {
+	if failed > 0 {
+		return fmt.Errorf("%d of %d keys failed", failed, total)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+var publishCmd = &cobra.Command{
+	Use:   "publish <channel> <message>",
+	Short: "Publish a message on a channel",
+	Long: `Publish a message on a named channel, creating the channel if needed.
+
+Prints the message ID. Pass it to 'subscribe --after' to read everything
+published since.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runPublish,
+}
+
+var subscribeCmd = &cobra.Command{
+	Use:   "subscribe <channel>",
+	Short: "Print messages published on a channel",
+	Long: `Subscribe to a named channel by polling Reddit, and print each new
+message until interrupted.
+
+Only messages published after subscribing are printed, unless --after
+gives the ID of a message to resume from. With --json, each message is
+printed as a line of JSON including its ID.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSubscribe,
+}
+
+var (
+	flagSubscribeAfter    string
+	flagSubscribeInterval time.Duration
+	flagSubscribeJSON     bool
+)
+
+func init() {
+	subscribeCmd.Flags().StringVar(&flagSubscribeAfter, "after", "", "Resume after the message with this ID")
+	subscribeCmd.Flags().DurationVar(&flagSubscribeInterval, "interval", 10*time.Second, "How often to poll Reddit")
+	subscribeCmd.Flags().BoolVar(&flagSubscribeJSON, "json", false, "Print messages as JSON")
+}
+
+func runPublish(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	msg, err := client.Publish(args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(msg.ID)
+	return nil
+}
+
+func runSubscribe(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(cfg, redditkv.WithPollInterval(flagSubscribeInterval))
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var messages <-chan redditkv.Message
+	if cmd.Flags().Changed("after") {
+		messages, err = client.SubscribeAfter(ctx, args[0], flagSubscribeAfter)
+	} else {
+		messages, err = client.Subscribe(ctx, args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	for msg := range messages {
+		if !flagSubscribeJSON {
+			fmt.Println(msg.Body)
+			continue
+		}
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("failed to marshal message: %w", err)
+		}
+		fmt.Println(string(data))
+	}
+	return nil
+}
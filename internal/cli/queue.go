@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Inspect job queues",
+	Long: `Inspect job queues. Each queue is a post whose top-level comments are
+its jobs; workers claim jobs by replying to them. Jobs claimed too many
+times without being acknowledged move to the queue's dead-letter queue,
+named '<queue>:dead'.`,
+}
+
+var queueDepthCmd = &cobra.Command{
+	Use:   "depth <queue>",
+	Short: "Count ready and in-flight jobs",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runQueueDepth,
+}
+
+var queueOldestCmd = &cobra.Command{
+	Use:   "oldest <queue>",
+	Short: "Show the oldest job in a queue",
+	Long: `Print the oldest job still in a queue, ready or in flight, as JSON.
+Prints (nil) if the queue is empty.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runQueueOldest,
+}
+
+func init() {
+	queueCmd.AddCommand(queueDepthCmd)
+	queueCmd.AddCommand(queueOldestCmd)
+}
+
+// queueJobEntry is a job as printed by 'queue oldest'.
+type queueJobEntry struct {
+	ID       string    `json:"id"`
+	Payload  string    `json:"payload"`
+	Enqueued time.Time `json:"enqueued"`
+	Age      string    `json:"age"`
+	Attempts int       `json:"attempts"`
+	Worker   string    `json:"worker,omitempty"`
+	Expires  time.Time `json:"expires,omitzero"`
+}
+
+func runQueueDepth(cmd *cobra.Command, args []string) error {
+	stats, err := queueStats(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("ready %d, in flight %d\n", stats.Ready, stats.InFlight)
+	return nil
+}
+
+func runQueueOldest(cmd *cobra.Command, args []string) error {
+	stats, err := queueStats(args[0])
+	if err != nil {
+		return err
+	}
+
+	job := stats.Oldest
+	if job == nil {
+		fmt.Println("(nil)")
+		return nil
+	}
+
+	entry := queueJobEntry{
+		ID:       job.ID,
+		Payload:  job.Payload,
+		Enqueued: job.Enqueued,
+		Age:      time.Since(job.Enqueued).Round(time.Second).String(),
+		Attempts: job.Attempt,
+		Worker:   job.Worker,
+		Expires:  job.Expires,
+	}
+
+	output, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	fmt.Println(string(output))
+	return nil
+}
+
+func queueStats(queue string) (*redditkv.QueueStats, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	return client.QueueStats(queue)
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/spf13/cobra"
+	"github.com/sprite/reddit-kv/internal/server"
+)
+
+var redisServerCmd = &cobra.Command{
+	Use:   "redis-server",
+	Short: "Serve the key-value API over the Redis protocol",
+	Long: `Serve a subset of the Redis protocol (RESP2/RESP3) so redis-cli and
+Redis client libraries can talk to the subreddit.
+
+Supported commands: GET, SET [NX|XX] [GET], DEL, EXISTS, KEYS, SCAN,
+APPEND key value [PARENT path], GETTREE, plus PING, ECHO, HELLO, SELECT 0
+and QUIT. GET returns the root value; GETTREE returns the whole value tree
+as JSON. Anything else is answered with an error.`,
+	Args: cobra.NoArgs,
+	RunE: runRedisServer,
+}
+
+var flagRedisListen string
+
+func init() {
+	redisServerCmd.Flags().StringVar(&flagRedisListen, "listen", ":6379", "Address to listen on")
+}
+
+func runRedisServer(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := newServerClient(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", flagRedisListen)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	fmt.Printf("Listening on %s\n", flagRedisListen)
+	return server.NewRESPServer(client).Serve(listener)
+}
@@ -3,8 +3,11 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/sprite/reddit-kv/pkg/redditkv"
 )
 
 var rootCmd = &cobra.Command{
@@ -26,11 +29,194 @@ func Execute() {
 	}
 }
 
+// TTLs for the on-disk cache shared between invocations. Values are
+// trusted briefly; post IDs for longer, which still saves the search.
+const (
+	cacheValueTTL = 10 * time.Second
+	cachePostTTL  = time.Minute
+)
+
+var (
+	flagBackend string
+	flagRecord  string
+	flagReplay  string
+	flagNoCache bool
+
+	flagWriteBehind bool
+)
+
+// loadConfig loads the saved config and applies the --backend, --record
+// and --replay overrides. A local backend or a replay works without a
+// config file, since neither needs credentials.
+func loadConfig() (*redditkv.Config, error) {
+	if flagBackend == "" && flagReplay == "" {
+		cfg, err := redditkv.LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Record = flagRecord
+		cfg.WriteBehind = cfg.WriteBehind || flagWriteBehind
+		return cfg, nil
+	}
+
+	cfg := &redditkv.Config{}
+	if redditkv.ConfigExists() {
+		var err error
+		cfg, err = redditkv.LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if flagBackend != "" {
+		cfg.Backend = flagBackend
+	}
+	cfg.Record = flagRecord
+	cfg.Replay = flagReplay
+	cfg.WriteBehind = cfg.WriteBehind || flagWriteBehind
+	return cfg, nil
+}
+
+// newClient creates a client with the on-disk cache, unless it's turned
+// off (see useCache).
+func newClient(cfg *redditkv.Config, opts ...redditkv.Option) (*redditkv.KVClient, error) {
+	if useCache(cfg) {
+		if path, err := redditkv.CachePath(*cfg); err == nil {
+			opts = append(opts, redditkv.WithDiskCache(path, redditkv.CacheConfig{
+				ValueTTL: cacheValueTTL,
+				PostTTL:  cachePostTTL,
+			}))
+		}
+	}
+	return redditkv.New(*cfg, opts...)
+}
+
+// newServerClient creates a client for the long-running servers. Their
+// cache lives in memory: one process answers every request, so the disk
+// cache would only add a file read and write to each of them. The servers
+// write to Reddit directly, so they refuse to run in write-behind mode,
+// where they would reorder writes with the queue.
+func newServerClient(cmd *cobra.Command, cfg *redditkv.Config) (*redditkv.KVClient, error) {
+	if cfg.WriteBehind {
+		return nil, errWriteBehind(cmd.Name())
+	}
+
+	var opts []redditkv.Option
+	if useCache(cfg) {
+		opts = append(opts, redditkv.WithCache(redditkv.CacheConfig{
+			ValueTTL: cacheValueTTL,
+			PostTTL:  cachePostTTL,
+		}))
+	}
+	client, err := redditkv.New(*cfg, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return client, nil
+}
+
+// useCache reports whether to cache reads. Caching is turned off with
+// --no-cache, and is pointless with a local backend, which is as fast as
+// the cache, or with recordings, which must see every API call.
+func useCache(cfg *redditkv.Config) bool {
+	return !flagNoCache && cfg.Record == "" && cfg.Replay == "" &&
+		!strings.HasPrefix(cfg.Backend, "local:")
+}
+
+// storeFor returns what plain reads and writes should go through: the
+// client itself, or a write-behind queue in front of it.
+func storeFor(cfg *redditkv.Config, client *redditkv.KVClient) (redditkv.Client, error) {
+	if !cfg.WriteBehind {
+		return client, nil
+	}
+	return newWriteBehind(cfg, client, "")
+}
+
+func newWriteBehind(cfg *redditkv.Config, client *redditkv.KVClient, policy redditkv.ConflictPolicy) (*redditkv.WriteBehind, error) {
+	path, err := redditkv.QueuePath(*cfg)
+	if err != nil {
+		return nil, err
+	}
+	return redditkv.NewWriteBehind(client, redditkv.WriteBehindConfig{Path: path, Policy: policy}), nil
+}
+
+// newKVClient loads the config and creates a client that talks to Reddit
+// directly, for commands that bypass write-behind.
+func newKVClient() (*redditkv.KVClient, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return client, nil
+}
+
+// newKVWriter is newKVClient for commands that write to keys directly.
+// Those writes would bypass anything still queued in write-behind mode, so
+// the commands refuse to run in it.
+func newKVWriter(cmd *cobra.Command) (*redditkv.KVClient, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.WriteBehind {
+		return nil, errWriteBehind(cmd.Name())
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return client, nil
+}
+
+// errWriteBehind rejects commands that need Reddit itself.
+func errWriteBehind(what string) error {
+	return fmt.Errorf("%s doesn't work in write-behind mode", what)
+}
+
 func init() {
+	rootCmd.PersistentFlags().StringVar(&flagBackend, "backend", "", "Storage backend: 'reddit' or 'local:/path/to/db.json' (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&flagRecord, "record", "", "Record all API calls to this cassette file")
+	rootCmd.PersistentFlags().StringVar(&flagReplay, "replay", "", "Answer API calls from this cassette file instead of the backend")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+	rootCmd.PersistentFlags().BoolVar(&flagNoCache, "no-cache", false, "Don't use or update the on-disk cache")
+	rootCmd.PersistentFlags().BoolVar(&flagWriteBehind, "write-behind", false, "Queue writes locally; send them with 'reddit-kv flush'")
+
 	rootCmd.AddCommand(authCmd)
 	rootCmd.AddCommand(setCmd)
 	rootCmd.AddCommand(getCmd)
 	rootCmd.AddCommand(appendCmd)
 	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(mgetCmd)
+	rootCmd.AddCommand(msetCmd)
+	rootCmd.AddCommand(mdelCmd)
 	rootCmd.AddCommand(keysCmd)
+	rootCmd.AddCommand(fsckCmd)
+	rootCmd.AddCommand(lockCmd)
+	rootCmd.AddCommand(unlockCmd)
+	rootCmd.AddCommand(runLockedCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(redisServerCmd)
+	rootCmd.AddCommand(memcachedCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(flushCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(publishCmd)
+	rootCmd.AddCommand(subscribeCmd)
+	rootCmd.AddCommand(queueCmd)
+	rootCmd.AddCommand(xaddCmd)
+	rootCmd.AddCommand(xrangeCmd)
+	rootCmd.AddCommand(xrevrangeCmd)
+	rootCmd.AddCommand(xtrimCmd)
+	rootCmd.AddCommand(incrCmd)
+	rootCmd.AddCommand(decrCmd)
+	rootCmd.AddCommand(hsetCmd)
+	rootCmd.AddCommand(hgetCmd)
+	rootCmd.AddCommand(hdelCmd)
+	rootCmd.AddCommand(hgetallCmd)
+	rootCmd.AddCommand(hkeysCmd)
 }
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+	"github.com/sprite/reddit-kv/internal/server"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the key-value API over HTTP",
+	Long: `Serve a JSON REST API backed by the configured subreddit:
+
+  GET    /keys?match=<glob>               list keys
+  GET    /keys/{key}                      get a value tree
+  PUT    /keys/{key}                      set a value (request body)
+  DELETE /keys/{key}                      delete a key
+  POST   /keys/{key}/append?parent=0,1    append a value (request body)
+
+Slashes in keys are sent percent-encoded as %2F.`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+var flagListen string
+
+func init() {
+	serveCmd.Flags().StringVar(&flagListen, "listen", ":8080", "Address to listen on")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := newServerClient(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Listening on %s\n", flagListen)
+	return http.ListenAndServe(flagListen, server.NewHTTPHandler(client))
+}
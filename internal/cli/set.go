@@ -1,10 +1,10 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/sprite/reddit-kv/pkg/redditkv"
 )
 
 var setCmd = &cobra.Command{
@@ -12,27 +12,101 @@ var setCmd = &cobra.Command{
 	Short: "Set a key to a value",
 	Long: `Set a key to a value. If the key already exists, it will be overwritten.
 
-The key becomes a Reddit post title, and the value becomes a comment.`,
+The key becomes a Reddit post title, and the value becomes a comment.
+
+Use --if-version to only write if the key still has the version printed by
+'get --version'. An empty version ('--if-version=') requires that the key
+doesn't exist yet.
+
+Conditional modes:
+  --nx   only set if the key doesn't exist
+  --xx   only set if the key already exists
+  --get  print the previous value (JSON) before overwriting
+
+--nx and --xx print "(nil)" instead of "OK" when nothing was written.`,
 	Args: cobra.ExactArgs(2),
 	RunE: runSet,
 }
 
+var (
+	flagIfVersion string
+	flagNX        bool
+	flagXX        bool
+	flagGetOld    bool
+)
+
+func init() {
+	setCmd.Flags().StringVar(&flagIfVersion, "if-version", "", "Only set if the key's current version matches")
+	setCmd.Flags().BoolVar(&flagNX, "nx", false, "Only set if the key does not exist")
+	setCmd.Flags().BoolVar(&flagXX, "xx", false, "Only set if the key already exists")
+	setCmd.Flags().BoolVar(&flagGetOld, "get", false, "Print the previous value before overwriting")
+	setCmd.MarkFlagsMutuallyExclusive("if-version", "nx", "xx", "get")
+}
+
 func runSet(cmd *cobra.Command, args []string) error {
 	key := args[0]
 	value := args[1]
 
-	cfg, err := redditkv.LoadConfig()
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	client, err := redditkv.New(*cfg)
+	client, err := newClient(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
-	if err := client.Set(key, value); err != nil {
-		return fmt.Errorf("failed to set key: %w", err)
+	conditional := flagNX || flagXX || flagGetOld || cmd.Flags().Changed("if-version")
+	if cfg.WriteBehind && conditional {
+		return errWriteBehind("conditional set")
+	}
+
+	switch {
+	case flagNX, flagXX:
+		var written bool
+		if flagNX {
+			written, err = client.SetIfAbsent(key, value)
+		} else {
+			written, err = client.SetIfPresent(key, value)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to set key: %w", err)
+		}
+		if !written {
+			fmt.Println("(nil)")
+			return nil
+		}
+
+	case flagGetOld:
+		old, err := client.GetSet(key, value)
+		if err != nil {
+			return fmt.Errorf("failed to set key: %w", err)
+		}
+		if old == nil {
+			fmt.Println("(nil)")
+			return nil
+		}
+		output, err := json.MarshalIndent(old, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal value: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+
+	case cmd.Flags().Changed("if-version"):
+		if err := client.CompareAndSet(key, flagIfVersion, value); err != nil {
+			return fmt.Errorf("failed to set key: %w", err)
+		}
+
+	default:
+		store, err := storeFor(cfg, client)
+		if err != nil {
+			return err
+		}
+		if err := store.Set(key, value); err != nil {
+			return fmt.Errorf("failed to set key: %w", err)
+		}
 	}
 
 	fmt.Printf("OK\n")
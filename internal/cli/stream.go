@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+var xaddCmd = &cobra.Command{
+	Use:   "xadd <key> <value>",
+	Short: "Add an entry to a stream",
+	Long: `Add an entry to a stream, creating it if needed, and print the entry's
+ID. A stream is a key whose top-level comments are its entries; entry IDs
+look like "<unix ms>-<comment ID>".`,
+	Args: cobra.ExactArgs(2),
+	RunE: runXAdd,
+}
+
+var xrangeCmd = &cobra.Command{
+	Use:   "xrange <key> [start] [end]",
+	Short: "Print a stream's entries, oldest first",
+	Long: `Print the entries of a stream from start to end as JSON, oldest first.
+
+Bounds default to "-" and "+", the first and last entries. A bound can be
+an entry ID or a bare unix time in milliseconds; prefix it with "(" to
+leave out entries that match it exactly.`,
+	Args: cobra.RangeArgs(1, 3),
+	RunE: runXRange,
+}
+
+var xrevrangeCmd = &cobra.Command{
+	Use:   "xrevrange <key> [end] [start]",
+	Short: "Print a stream's entries, newest first",
+	Long: `Print the entries of a stream from end down to start as JSON, newest
+first. Bounds are as for xrange. Use --count to print the last N entries.`,
+	Args: cobra.RangeArgs(1, 3),
+	RunE: runXRevRange,
+}
+
+var xtrimCmd = &cobra.Command{
+	Use:   "xtrim <key>",
+	Short: "Remove a stream's oldest entries",
+	Long: `Remove a stream's oldest entries until it has at most --maxlen entries
+and none older than --maxage, and print how many were removed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runXTrim,
+}
+
+var (
+	flagXRevRangeCount int
+	flagXTrimMaxLen    int
+	flagXTrimMaxAge    time.Duration
+)
+
+func init() {
+	xrevrangeCmd.Flags().IntVar(&flagXRevRangeCount, "count", 0, "Print at most this many entries")
+	xtrimCmd.Flags().IntVar(&flagXTrimMaxLen, "maxlen", 0, "Keep at most this many entries")
+	xtrimCmd.Flags().DurationVar(&flagXTrimMaxAge, "maxage", 0, "Remove entries older than this")
+}
+
+func runXAdd(cmd *cobra.Command, args []string) error {
+	client, err := newKVWriter(cmd)
+	if err != nil {
+		return err
+	}
+
+	id, err := client.XAdd(args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(id)
+	return nil
+}
+
+func runXRange(cmd *cobra.Command, args []string) error {
+	client, err := newKVClient()
+	if err != nil {
+		return err
+	}
+
+	start, end := streamBounds(args, "-", "+")
+	entries, err := client.XRange(args[0], start, end)
+	if err != nil {
+		return err
+	}
+	return printEntries(entries)
+}
+
+func runXRevRange(cmd *cobra.Command, args []string) error {
+	client, err := newKVClient()
+	if err != nil {
+		return err
+	}
+
+	end, start := streamBounds(args, "+", "-")
+	entries, err := client.XRevRange(args[0], end, start, flagXRevRangeCount)
+	if err != nil {
+		return err
+	}
+	return printEntries(entries)
+}
+
+func runXTrim(cmd *cobra.Command, args []string) error {
+	if flagXTrimMaxLen <= 0 && flagXTrimMaxAge <= 0 {
+		return fmt.Errorf("xtrim needs --maxlen or --maxage")
+	}
+
+	client, err := newKVWriter(cmd)
+	if err != nil {
+		return err
+	}
+
+	removed, err := client.XTrim(args[0], redditkv.TrimOptions{
+		MaxLen: flagXTrimMaxLen,
+		MaxAge: flagXTrimMaxAge,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("trimmed %d\n", removed)
+	return nil
+}
+
+// streamBounds returns the two optional bounds after the key, or the
+// given defaults.
+func streamBounds(args []string, first, second string) (string, string) {
+	if len(args) > 1 {
+		first = args[1]
+	}
+	if len(args) > 2 {
+		second = args[2]
+	}
+	return first, second
+}
+
+func printEntries(entries []redditkv.StreamEntry) error {
+	output, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal entries: %w", err)
+	}
+	fmt.Println(string(output))
+	return nil
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [key]",
+	Short: "Watch a key, or the whole store, for changes",
+	Long: `Watch a key for changes by polling Reddit, and print each change as a
+line of JSON until interrupted. Without a key, watch the subreddit for keys
+being created or replaced.
+
+Event kinds: created, replaced, appended, modified, deleted.
+
+With --exec, the command is run through 'sh -c' for each change instead
+of printing it. It gets the event's JSON on stdin and REDDIT_KV_KEY,
+REDDIT_KV_EVENT and REDDIT_KV_VERSION in its environment. A failing
+command is reported but doesn't stop the watch.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runWatch,
+}
+
+var (
+	flagWatchExec     string
+	flagWatchInterval time.Duration
+)
+
+func init() {
+	watchCmd.Flags().StringVar(&flagWatchExec, "exec", "", "Shell command to run for each change")
+	watchCmd.Flags().DurationVar(&flagWatchInterval, "interval", 10*time.Second, "How often to poll Reddit")
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(cfg, redditkv.WithPollInterval(flagWatchInterval))
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var events <-chan redditkv.ChangeEvent
+	if len(args) == 1 {
+		events, err = client.Watch(ctx, args[0])
+	} else {
+		events, err = client.WatchKeys(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to watch: %w", err)
+	}
+
+	for event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+
+		if flagWatchExec == "" {
+			fmt.Println(string(data))
+			continue
+		}
+
+		hook := exec.CommandContext(ctx, "sh", "-c", flagWatchExec)
+		hook.Stdin = bytes.NewReader(append(data, '\n'))
+		hook.Stdout = os.Stdout
+		hook.Stderr = os.Stderr
+		hook.Env = append(os.Environ(),
+			"REDDIT_KV_KEY="+event.Key,
+			"REDDIT_KV_EVENT="+string(event.Kind),
+			"REDDIT_KV_VERSION="+event.Version,
+		)
+		if err := hook.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s hook for %s failed: %v\n", event.Kind, event.Key, err)
+		}
+	}
+	return nil
+}
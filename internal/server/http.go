@@ -0,0 +1,234 @@
+// Package server exposes a reddit-kv Client over network protocols.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+// maxValueSize caps request bodies. Reddit comments max out at 10,000
+// characters, so anything much larger can't be stored anyway.
+const maxValueSize = 64 * 1024
+
+// NewHTTPHandler returns a JSON REST API for the client:
+//
+//	GET    /keys?match=<glob>               list keys
+//	GET    /keys/{key}                      get a value tree
+//	PUT    /keys/{key}                      set a value (request body)
+//	DELETE /keys/{key}                      delete a key
+//	POST   /keys/{key}/append?parent=0,1    append a value (request body)
+//
+// Keys are a single path segment, so slashes in a key are sent
+// percent-encoded as %2F.
+func NewHTTPHandler(client redditkv.Client) http.Handler {
+	h := &httpHandler{client: client}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /keys", h.keys)
+	mux.HandleFunc("GET /keys/{key}", h.get)
+	mux.HandleFunc("PUT /keys/{key}", h.set)
+	mux.HandleFunc("DELETE /keys/{key}", h.delete)
+	mux.HandleFunc("POST /keys/{key}/append", h.append)
+	return mux
+}
+
+type httpHandler struct {
+	client redditkv.Client
+}
+
+func (h *httpHandler) keys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.client.Keys()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if match := r.URL.Query().Get("match"); match != "" {
+		keys = filterKeys(keys, match)
+	}
+
+	writeJSON(w, http.StatusOK, keys)
+}
+
+func (h *httpHandler) get(w http.ResponseWriter, r *http.Request) {
+	value, err := h.client.Get(r.PathValue("key"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, value)
+}
+
+func (h *httpHandler) set(w http.ResponseWriter, r *http.Request) {
+	value, ok := readValue(w, r)
+	if !ok {
+		return
+	}
+	if err := h.client.Set(r.PathValue("key"), value); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *httpHandler) delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.client.Delete(r.PathValue("key")); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *httpHandler) append(w http.ResponseWriter, r *http.Request) {
+	parentPath, err := redditkv.ParsePath(r.URL.Query().Get("parent"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
+		return
+	}
+
+	value, ok := readValue(w, r)
+	if !ok {
+		return
+	}
+	if err := h.client.Append(r.PathValue("key"), value, parentPath); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readValue reads the request body as the value to store.
+func readValue(w http.ResponseWriter, r *http.Request) (string, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxValueSize))
+	if err != nil {
+		writeJSON(w, http.StatusRequestEntityTooLarge, errorBody{Error: "value too large"})
+		return "", false
+	}
+	return string(body), true
+}
+
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// writeError maps client errors to HTTP status codes.
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, httpStatus(err), errorBody{Error: err.Error()})
+}
+
+func httpStatus(err error) int {
+	var (
+		notFound    *redditkv.KeyNotFoundError
+		invalidPath *redditkv.InvalidPathError
+		conflict    *redditkv.ConflictError
+		archived    *redditkv.ArchivedKeyError
+		locked      *redditkv.LockedKeyError
+		removed     *redditkv.RemovedValueError
+	)
+	switch {
+	case errors.As(err, &notFound):
+		return http.StatusNotFound
+	case errors.As(err, &invalidPath):
+		return http.StatusBadRequest
+	case errors.As(err, &conflict), errors.As(err, &archived):
+		return http.StatusConflict
+	case errors.As(err, &locked):
+		return http.StatusLocked
+	case errors.As(err, &removed):
+		return http.StatusGone
+	}
+	return http.StatusBadGateway
+}
+
+// filterKeys returns the keys matching a Redis-style glob pattern.
+func filterKeys(keys []string, pattern string) []string {
+	matched := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if globMatch(pattern, key) {
+			matched = append(matched, key)
+		}
+	}
+	return matched
+}
+
+// globMatch reports whether s matches pattern the way Redis's KEYS does:
+// "*" matches any run of bytes, "/" included, "?" matches one byte, "[...]"
+// matches a set of bytes ("[^...]" negates it, "a-z" is a range) and "\"
+// escapes the next byte. Unlike path.Match, no pattern is invalid.
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := range len(s) + 1 {
+				if globMatch(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			set := pattern[1:]
+			negate := len(set) > 0 && set[0] == '^'
+			if negate {
+				set = set[1:]
+			}
+			matched := false
+			for len(set) > 0 && set[0] != ']' {
+				switch {
+				case set[0] == '\\' && len(set) > 1:
+					matched = matched || set[1] == s[0]
+					set = set[2:]
+				case len(set) > 2 && set[1] == '-':
+					lo, hi := min(set[0], set[2]), max(set[0], set[2])
+					matched = matched || (lo <= s[0] && s[0] <= hi)
+					set = set[3:]
+				default:
+					matched = matched || set[0] == s[0]
+					set = set[1:]
+				}
+			}
+			if matched == negate {
+				return false
+			}
+			// An unterminated set runs to the end of the pattern
+			pattern = set
+			if len(pattern) == 0 {
+				return len(s) == 1
+			}
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		s = s[1:]
+	}
+	return len(s) == 0
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
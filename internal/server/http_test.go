@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	client := redditkv.NewWithAPI(redditkv.NewMockRedditAPI(), "testsubreddit")
+	srv := httptest.NewServer(NewHTTPHandler(client))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func doRequest(t *testing.T, method, url, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestHTTPSetGetDelete(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp := doRequest(t, http.MethodPut, srv.URL+"/keys/greeting", "hello")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("PUT: expected 204, got %d", resp.StatusCode)
+	}
+
+	resp = doRequest(t, http.MethodGet, srv.URL+"/keys/greeting", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET: expected 200, got %d", resp.StatusCode)
+	}
+	var value redditkv.ValueNode
+	if err := json.NewDecoder(resp.Body).Decode(&value); err != nil {
+		t.Fatalf("Failed to decode value: %v", err)
+	}
+	if value.Value != "hello" {
+		t.Errorf("Expected value 'hello', got '%s'", value.Value)
+	}
+
+	resp = doRequest(t, http.MethodDelete, srv.URL+"/keys/greeting", "")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE: expected 204, got %d", resp.StatusCode)
+	}
+
+	resp = doRequest(t, http.MethodGet, srv.URL+"/keys/greeting", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET deleted key: expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPKeyWithSlash(t *testing.T) {
+	srv := newTestServer(t)
+	keyURL := srv.URL + "/keys/" + url.PathEscape("config/app/name")
+
+	resp := doRequest(t, http.MethodPut, keyURL, "reddit-kv")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("PUT: expected 204, got %d", resp.StatusCode)
+	}
+	resp = doRequest(t, http.MethodPost, keyURL+"/append?parent=0", "child")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("append: expected 204, got %d", resp.StatusCode)
+	}
+
+	resp = doRequest(t, http.MethodGet, keyURL, "")
+	var value redditkv.ValueNode
+	if err := json.NewDecoder(resp.Body).Decode(&value); err != nil {
+		t.Fatalf("Failed to decode value: %v", err)
+	}
+	if value.Value != "reddit-kv" || len(value.Children) != 1 {
+		t.Errorf("Expected the key with slashes, got %+v", value)
+	}
+
+	resp = doRequest(t, http.MethodGet, srv.URL+"/keys?match=config/*", "")
+	var keys []string
+	_ = json.NewDecoder(resp.Body).Decode(&keys)
+	if len(keys) != 1 || keys[0] != "config/app/name" {
+		t.Errorf("Expected [config/app/name], got %v", keys)
+	}
+
+	resp = doRequest(t, http.MethodDelete, keyURL, "")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("DELETE: expected 204, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPAppend(t *testing.T) {
+	srv := newTestServer(t)
+
+	doRequest(t, http.MethodPut, srv.URL+"/keys/tree", "root")
+
+	resp := doRequest(t, http.MethodPost, srv.URL+"/keys/tree/append?parent=0", "child")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("append: expected 204, got %d", resp.StatusCode)
+	}
+
+	resp = doRequest(t, http.MethodPost, srv.URL+"/keys/tree/append?parent=5", "x")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("append to invalid path: expected 400, got %d", resp.StatusCode)
+	}
+
+	resp = doRequest(t, http.MethodPost, srv.URL+"/keys/missing/append", "x")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("append to missing key: expected 404, got %d", resp.StatusCode)
+	}
+
+	resp = doRequest(t, http.MethodGet, srv.URL+"/keys/tree", "")
+	var value redditkv.ValueNode
+	_ = json.NewDecoder(resp.Body).Decode(&value)
+	if len(value.Children) != 1 || value.Children[0].Value != "child" {
+		t.Errorf("Expected one child 'child', got %+v", value.Children)
+	}
+}
+
+func TestHTTPKeysMatch(t *testing.T) {
+	srv := newTestServer(t)
+
+	doRequest(t, http.MethodPut, srv.URL+"/keys/user:1", "a")
+	doRequest(t, http.MethodPut, srv.URL+"/keys/user:2", "b")
+	doRequest(t, http.MethodPut, srv.URL+"/keys/other", "c")
+
+	resp := doRequest(t, http.MethodGet, srv.URL+"/keys?match=user:*", "")
+	var keys []string
+	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
+		t.Fatalf("Failed to decode keys: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("Expected 2 matching keys, got %v", keys)
+	}
+}
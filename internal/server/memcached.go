@@ -0,0 +1,347 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+// maxRelativeExptime is memcached's cutoff between relative and absolute
+// expiration times: anything larger is a Unix timestamp.
+const maxRelativeExptime = 30 * 24 * 60 * 60
+
+// Metadata fields used to store memcached item attributes on a key.
+const (
+	metaFlags   = "flags"
+	metaExpires = "expires"
+)
+
+// MemcachedClient is the subset of KVClient the memcached frontend needs.
+type MemcachedClient interface {
+	redditkv.Client
+	SetWithMeta(key, value string, meta map[string]string) error
+	SetIfAbsentWithMeta(key, value string, meta map[string]string) (bool, error)
+	CompareAndSetWithMeta(key, expectedVersion, value string, meta map[string]string) error
+}
+
+// MemcachedServer speaks the memcached text protocol:
+//
+//	get, gets, set, add, replace, append, cas, delete, version, quit
+//
+// Flags and expiration times are stored as key metadata. Expired keys are
+// treated as missing and deleted lazily on the next read. A key's value
+// is its tree flattened depth-first, so memcached append maps onto Append.
+type MemcachedServer struct {
+	client MemcachedClient
+}
+
+// NewMemcachedServer creates a memcached protocol frontend for the client.
+func NewMemcachedServer(client MemcachedClient) *MemcachedServer {
+	return &MemcachedServer{client: client}
+}
+
+// Serve accepts connections on l until it is closed.
+func (s *MemcachedServer) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn handles a single client connection until it disconnects.
+func (s *MemcachedServer) ServeConn(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		line, err := readLine(r)
+		if errors.Is(err, errLineTooLong) {
+			w.WriteString("CLIENT_ERROR line too long\r\n")
+			_ = w.Flush()
+		}
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		quit, err := s.dispatch(r, w, fields)
+		if err != nil {
+			return
+		}
+		if err := w.Flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+// memcachedItem is a stored value as memcached sees it.
+type memcachedItem struct {
+	value   string
+	flags   uint32
+	cas     uint64
+	version string // the key's version token, which cas is derived from
+}
+
+// dispatch runs one command. It reports whether the connection should
+// close; an error means the stream can't be resynchronized.
+func (s *MemcachedServer) dispatch(r *bufio.Reader, w *bufio.Writer, fields []string) (bool, error) {
+	cmd, args := fields[0], fields[1:]
+
+	switch cmd {
+	case "get", "gets":
+		s.get(w, args, cmd == "gets")
+	case "set", "add", "replace", "append", "cas":
+		return false, s.store(r, w, cmd, args)
+	case "delete":
+		s.delete(w, args)
+	case "version":
+		w.WriteString("VERSION reddit-kv\r\n")
+	case "quit":
+		return true, nil
+	default:
+		w.WriteString("ERROR\r\n")
+	}
+	return false, nil
+}
+
+func (s *MemcachedServer) get(w *bufio.Writer, keys []string, withCAS bool) {
+	if len(keys) == 0 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+
+	for _, key := range keys {
+		item, err := s.lookup(key)
+		if err != nil {
+			w.WriteString(serverError(err) + "\r\n")
+			return
+		}
+		if item == nil {
+			continue
+		}
+		if withCAS {
+			fmt.Fprintf(w, "VALUE %s %d %d %d\r\n", key, item.flags, len(item.value), item.cas)
+		} else {
+			fmt.Fprintf(w, "VALUE %s %d %d\r\n", key, item.flags, len(item.value))
+		}
+		w.WriteString(item.value)
+		w.WriteString("\r\n")
+	}
+	w.WriteString("END\r\n")
+}
+
+// store handles set, add, replace, append and cas:
+//
+//	<cmd> <key> <flags> <exptime> <bytes> [<cas unique>] [noreply]
+func (s *MemcachedServer) store(r *bufio.Reader, w *bufio.Writer, cmd string, args []string) error {
+	want := 4
+	if cmd == "cas" {
+		want = 5
+	}
+	if len(args) < want || len(args) > want+1 {
+		w.WriteString("ERROR\r\n")
+		return nil
+	}
+
+	key := args[0]
+	flags, errFlags := strconv.ParseUint(args[1], 10, 32)
+	exptime, errExp := strconv.ParseInt(args[2], 10, 64)
+	size, errSize := strconv.Atoi(args[3])
+	if errFlags != nil || errExp != nil || errSize != nil || size < 0 {
+		w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return nil
+	}
+	if size > maxValueSize {
+		// Skip the data without buffering it, as memcached does
+		w.WriteString("SERVER_ERROR object too large for cache\r\n")
+		_, err := io.CopyN(io.Discard, r, int64(size)+2)
+		return err
+	}
+	var casUnique uint64
+	if cmd == "cas" {
+		var err error
+		casUnique, err = strconv.ParseUint(args[4], 10, 64)
+		if err != nil {
+			w.WriteString("CLIENT_ERROR bad command line format\r\n")
+			return nil
+		}
+	}
+	noreply := len(args) == want+1 && args[want] == "noreply"
+
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+	if string(data[size:]) != "\r\n" {
+		w.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return nil
+	}
+	value := string(data[:size])
+
+	reply := s.apply(cmd, key, value, uint32(flags), exptime, casUnique)
+	if !noreply {
+		w.WriteString(reply + "\r\n")
+	}
+	return nil
+}
+
+// apply performs a storage command and returns the protocol reply.
+func (s *MemcachedServer) apply(cmd, key, value string, flags uint32, exptime int64, casUnique uint64) string {
+	var item *memcachedItem
+	if cmd != "set" {
+		var err error
+		item, err = s.lookup(key)
+		if err != nil {
+			return serverError(err)
+		}
+		switch {
+		case cmd == "add" && item != nil:
+			return "NOT_STORED"
+		case (cmd == "replace" || cmd == "append") && item == nil:
+			return "NOT_STORED"
+		case cmd == "cas" && item == nil:
+			return "NOT_FOUND"
+		case cmd == "cas" && item.cas != casUnique:
+			return "EXISTS"
+		}
+	}
+
+	if cmd == "append" {
+		// Append keeps the existing flags and expiry
+		if err := s.client.Append(key, value, nil); err != nil {
+			return serverError(err)
+		}
+		return "STORED"
+	}
+
+	meta := map[string]string{metaFlags: strconv.FormatUint(uint64(flags), 10)}
+	if expires, ok := expiresAt(exptime, time.Now()); ok {
+		meta[metaExpires] = strconv.FormatInt(expires.Unix(), 10)
+	}
+
+	// add and cas must not overwrite a concurrent writer, so they go
+	// through the client's race checks rather than a plain set
+	var err error
+	switch cmd {
+	case "add":
+		var stored bool
+		stored, err = s.client.SetIfAbsentWithMeta(key, value, meta)
+		if err == nil && !stored {
+			return "NOT_STORED"
+		}
+	case "cas":
+		err = s.client.CompareAndSetWithMeta(key, item.version, value, meta)
+		var conflict *redditkv.ConflictError
+		if errors.As(err, &conflict) {
+			return "EXISTS"
+		}
+	default:
+		err = s.client.SetWithMeta(key, value, meta)
+	}
+	if err != nil {
+		return serverError(err)
+	}
+	return "STORED"
+}
+
+func (s *MemcachedServer) delete(w *bufio.Writer, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+	noreply := len(args) == 2 && args[1] == "noreply"
+
+	reply := "DELETED"
+	err := s.client.Delete(args[0])
+	var notFound *redditkv.KeyNotFoundError
+	switch {
+	case errors.As(err, &notFound):
+		reply = "NOT_FOUND"
+	case err != nil:
+		reply = serverError(err)
+	}
+	if !noreply {
+		w.WriteString(reply + "\r\n")
+	}
+}
+
+// lookup fetches a key as a memcached item. It returns nil for missing
+// and expired keys; expired keys are deleted on the way.
+func (s *MemcachedServer) lookup(key string) (*memcachedItem, error) {
+	value, err := s.client.Get(key)
+	var notFound *redditkv.KeyNotFoundError
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if expires, ok := value.Meta[metaExpires]; ok {
+		if unix, err := strconv.ParseInt(expires, 10, 64); err == nil && time.Now().Unix() >= unix {
+			_ = s.client.Delete(key)
+			return nil, nil
+		}
+	}
+
+	flags, _ := strconv.ParseUint(value.Meta[metaFlags], 10, 32)
+
+	h := fnv.New64a()
+	h.Write([]byte(value.Version))
+
+	return &memcachedItem{
+		value:   flatten(value),
+		flags:   uint32(flags),
+		cas:     h.Sum64(),
+		version: value.Version,
+	}, nil
+}
+
+// serverError formats a SERVER_ERROR reply.
+func serverError(err error) string {
+	return "SERVER_ERROR " + oneLine(err.Error())
+}
+
+// flatten concatenates a value tree depth-first.
+func flatten(node *redditkv.ValueNode) string {
+	var b strings.Builder
+	var walk func(n *redditkv.ValueNode)
+	walk = func(n *redditkv.ValueNode) {
+		b.WriteString(n.Value)
+		for i := range n.Children {
+			walk(&n.Children[i])
+		}
+	}
+	walk(node)
+	return b.String()
+}
+
+// expiresAt converts a memcached exptime to an absolute time. It reports
+// false if the item never expires.
+func expiresAt(exptime int64, now time.Time) (time.Time, bool) {
+	switch {
+	case exptime == 0:
+		return time.Time{}, false
+	case exptime < 0:
+		return now, true
+	case exptime <= maxRelativeExptime:
+		return now.Add(time.Duration(exptime) * time.Second), true
+	default:
+		return time.Unix(exptime, 0), true
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+func newMemcachedConn(t *testing.T) *testConn {
+	t.Helper()
+	client := redditkv.NewWithAPI(redditkv.NewMockRedditAPI(), "testsubreddit")
+	return newTestConn(t, NewMemcachedServer(client).ServeConn)
+}
+
+func TestMemcachedSetGet(t *testing.T) {
+	c := newMemcachedConn(t)
+
+	c.send("get greeting\r\n")
+	c.expect("END")
+
+	c.send("set greeting 42 0 5\r\nhello\r\n")
+	c.expect("STORED")
+
+	c.send("get greeting\r\n")
+	c.expect("VALUE greeting 42 5", "hello", "END")
+
+	c.send("delete greeting\r\n")
+	c.expect("DELETED")
+
+	c.send("delete greeting\r\n")
+	c.expect("NOT_FOUND")
+}
+
+func TestMemcachedAddReplaceAppend(t *testing.T) {
+	c := newMemcachedConn(t)
+
+	c.send("replace k 0 0 1\r\na\r\n")
+	c.expect("NOT_STORED")
+
+	c.send("append k 0 0 1\r\na\r\n")
+	c.expect("NOT_STORED")
+
+	c.send("add k 7 0 3\r\nfoo\r\n")
+	c.expect("STORED")
+
+	c.send("add k 0 0 3\r\nbar\r\n")
+	c.expect("NOT_STORED")
+
+	c.send("append k 0 0 3\r\nbar\r\n")
+	c.expect("STORED")
+
+	c.send("get k\r\n")
+	c.expect("VALUE k 7 6", "foobar", "END")
+}
+
+func TestMemcachedCAS(t *testing.T) {
+	c := newMemcachedConn(t)
+
+	c.send("cas k 0 0 1 123\r\na\r\n")
+	c.expect("NOT_FOUND")
+
+	c.send("set k 0 0 2\r\nv1\r\n")
+	c.expect("STORED")
+
+	c.send("gets k\r\n")
+	header := c.line()
+	fields := strings.Fields(header)
+	if len(fields) != 5 {
+		t.Fatalf("Expected VALUE line with cas, got %q", header)
+	}
+	c.expect("v1", "END")
+	cas := fields[4]
+
+	c.send("cas k 0 0 2 " + cas + "\r\nv2\r\n")
+	c.expect("STORED")
+
+	// The old cas value is stale now
+	c.send("cas k 0 0 2 " + cas + "\r\nv3\r\n")
+	c.expect("EXISTS")
+}
+
+func TestMemcachedConcurrentAddAndCAS(t *testing.T) {
+	mock := redditkv.NewMockRedditAPI()
+	mock.SetLatency(5 * time.Millisecond)
+	client := redditkv.NewWithAPI(mock, "testsubreddit", redditkv.WithSettleDelay(20*time.Millisecond))
+	server := NewMemcachedServer(client)
+	c1 := newTestConn(t, server.ServeConn)
+	c2 := newTestConn(t, server.ServeConn)
+
+	// Both commands are in flight before either is answered
+	c1.send("add k 0 0 2\r\nv1\r\n")
+	c2.send("add k 0 0 2\r\nv2\r\n")
+	replies := []string{c1.line(), c2.line()}
+	slices.Sort(replies)
+	if fmt.Sprint(replies) != "[NOT_STORED STORED]" {
+		t.Fatalf("Expected one add to be stored, got %v", replies)
+	}
+
+	c1.send("gets k\r\n")
+	cas := strings.Fields(c1.line())[4]
+	c1.line()
+	c1.expect("END")
+
+	c1.send("cas k 0 0 2 " + cas + "\r\nv3\r\n")
+	c2.send("cas k 0 0 2 " + cas + "\r\nv4\r\n")
+	replies = []string{c1.line(), c2.line()}
+	slices.Sort(replies)
+	if fmt.Sprint(replies) != "[EXISTS STORED]" {
+		t.Fatalf("Expected one cas to be stored, got %v", replies)
+	}
+}
+
+func TestMemcachedLimits(t *testing.T) {
+	c := newMemcachedConn(t)
+
+	// An oversized value is refused and skipped without losing the stream
+	c.send(fmt.Sprintf("set k 0 0 %d\r\n%s\r\n", maxValueSize+1, strings.Repeat("a", maxValueSize+1)))
+	c.expect("SERVER_ERROR object too large for cache")
+	c.send("get k\r\n")
+	c.expect("END")
+
+	go c.conn.Write([]byte(strings.Repeat("a", 2*maxLineLength)))
+	c.expect("CLIENT_ERROR line too long")
+}
+
+func TestMemcachedErrorsStayOnOneLine(t *testing.T) {
+	c := newTestConn(t, NewMemcachedServer(brokenClient()).ServeConn)
+
+	c.send("get k\r\n")
+	if got := c.line(); !strings.HasPrefix(got, "SERVER_ERROR ") || !strings.HasSuffix(got, "boom  +OK") {
+		t.Errorf("Expected a single error line, got %q", got)
+	}
+	c.send("version\r\n")
+	c.expect("VERSION reddit-kv")
+}
+
+func TestMemcachedExpiry(t *testing.T) {
+	c := newMemcachedConn(t)
+
+	c.send("set k 0 -1 1\r\na\r\n")
+	c.expect("STORED")
+
+	c.send("get k\r\n")
+	c.expect("END")
+
+	c.send("incr k 1\r\n")
+	c.expect("ERROR")
+
+	c.send("set k 0 0 1 noreply\r\nb\r\nget k\r\n")
+	c.expect("VALUE k 0 1", "b", "END")
+}
@@ -0,0 +1,562 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+// RedisClient is the subset of KVClient the Redis frontend needs.
+type RedisClient interface {
+	redditkv.Client
+	SetIfAbsent(key, value string) (bool, error)
+	SetIfPresent(key, value string) (bool, error)
+	GetSet(key, value string) (*redditkv.ValueNode, error)
+}
+
+// RESPServer speaks a subset of the Redis protocol (RESP2 and RESP3):
+//
+//	PING, ECHO, HELLO, QUIT, SELECT 0, COMMAND, CLIENT
+//	GET key                     root value of the key's tree
+//	SET key value [NX|XX] [GET]
+//	DEL key [key ...]
+//	EXISTS key [key ...]
+//	KEYS pattern
+//	SCAN cursor [MATCH pattern] [COUNT n]
+//	APPEND key value [PARENT path]   add a node, creating the key if missing;
+//	                                 replies with the number of values
+//	GETTREE key                 the full value tree as JSON
+type RESPServer struct {
+	client RedisClient
+}
+
+// NewRESPServer creates a Redis protocol frontend for the client.
+func NewRESPServer(client RedisClient) *RESPServer {
+	return &RESPServer{client: client}
+}
+
+// Serve accepts connections on l until it is closed.
+func (s *RESPServer) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn handles a single client connection until it disconnects.
+func (s *RESPServer) ServeConn(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	w := &respWriter{w: bufio.NewWriter(conn), proto: 2}
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				w.error("ERR Protocol error: " + err.Error())
+				_ = w.w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := s.dispatch(w, args)
+		if err := w.w.Flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+// dispatch runs one command. It reports whether the connection should close.
+func (s *RESPServer) dispatch(w *respWriter, args []string) bool {
+	name := strings.ToUpper(args[0])
+	args = args[1:]
+
+	switch name {
+	case "PING":
+		if len(args) > 0 {
+			w.bulk(args[0])
+		} else {
+			w.simple("PONG")
+		}
+	case "ECHO":
+		if len(args) != 1 {
+			w.wrongArgs(name)
+			return false
+		}
+		w.bulk(args[0])
+	case "QUIT":
+		w.simple("OK")
+		return true
+	case "HELLO":
+		s.hello(w, args)
+	case "SELECT":
+		if len(args) != 1 || args[0] != "0" {
+			w.error("ERR DB index is out of range")
+			return false
+		}
+		w.simple("OK")
+	case "COMMAND":
+		w.array(0)
+	case "CLIENT":
+		// Client libraries send CLIENT SETNAME/SETINFO on connect
+		w.simple("OK")
+	case "GET":
+		s.get(w, args)
+	case "GETTREE":
+		s.getTree(w, args)
+	case "SET":
+		s.set(w, args)
+	case "DEL":
+		s.del(w, args)
+	case "EXISTS":
+		s.exists(w, args)
+	case "KEYS":
+		s.keys(w, args)
+	case "SCAN":
+		s.scan(w, args)
+	case "APPEND":
+		s.append(w, args)
+	default:
+		w.error(fmt.Sprintf("ERR unknown command '%s'", strings.ToLower(name)))
+	}
+	return false
+}
+
+func (s *RESPServer) hello(w *respWriter, args []string) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "2":
+			w.proto = 2
+		case "3":
+			w.proto = 3
+		default:
+			w.error("NOPROTO unsupported protocol version")
+			return
+		}
+	}
+
+	w.mapHeader(3)
+	w.bulk("server")
+	w.bulk("reddit-kv")
+	w.bulk("proto")
+	w.integer(int64(w.proto))
+	w.bulk("mode")
+	w.bulk("standalone")
+}
+
+func (s *RESPServer) get(w *respWriter, args []string) {
+	if len(args) != 1 {
+		w.wrongArgs("get")
+		return
+	}
+
+	value, err := s.client.Get(args[0])
+	if err != nil {
+		s.nullOrError(w, err)
+		return
+	}
+	w.bulk(value.Value)
+}
+
+func (s *RESPServer) getTree(w *respWriter, args []string) {
+	if len(args) != 1 {
+		w.wrongArgs("gettree")
+		return
+	}
+
+	value, err := s.client.Get(args[0])
+	if err != nil {
+		s.nullOrError(w, err)
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		w.error("ERR " + err.Error())
+		return
+	}
+	w.bulk(string(data))
+}
+
+func (s *RESPServer) set(w *respWriter, args []string) {
+	if len(args) < 2 {
+		w.wrongArgs("set")
+		return
+	}
+	key, value := args[0], args[1]
+
+	var nx, xx, get bool
+	for _, opt := range args[2:] {
+		switch strings.ToUpper(opt) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "GET":
+			get = true
+		case "EX", "PX", "EXAT", "PXAT", "KEEPTTL":
+			w.error("ERR expiration is not supported")
+			return
+		default:
+			w.error("ERR syntax error")
+			return
+		}
+	}
+	if (nx && xx) || (get && (nx || xx)) {
+		w.error("ERR syntax error")
+		return
+	}
+
+	switch {
+	case get:
+		old, err := s.client.GetSet(key, value)
+		if err != nil {
+			w.error("ERR " + err.Error())
+			return
+		}
+		if old == nil {
+			w.null()
+			return
+		}
+		w.bulk(old.Value)
+	case nx, xx:
+		var written bool
+		var err error
+		if nx {
+			written, err = s.client.SetIfAbsent(key, value)
+		} else {
+			written, err = s.client.SetIfPresent(key, value)
+		}
+		if err != nil {
+			w.error("ERR " + err.Error())
+			return
+		}
+		if !written {
+			w.null()
+			return
+		}
+		w.simple("OK")
+	default:
+		if err := s.client.Set(key, value); err != nil {
+			w.error("ERR " + err.Error())
+			return
+		}
+		w.simple("OK")
+	}
+}
+
+func (s *RESPServer) del(w *respWriter, args []string) {
+	if len(args) == 0 {
+		w.wrongArgs("del")
+		return
+	}
+
+	var deleted int64
+	for _, key := range args {
+		err := s.client.Delete(key)
+		var notFound *redditkv.KeyNotFoundError
+		switch {
+		case err == nil:
+			deleted++
+		case errors.As(err, &notFound):
+		default:
+			w.error("ERR " + err.Error())
+			return
+		}
+	}
+	w.integer(deleted)
+}
+
+func (s *RESPServer) exists(w *respWriter, args []string) {
+	if len(args) == 0 {
+		w.wrongArgs("exists")
+		return
+	}
+
+	var count int64
+	for _, key := range args {
+		ok, err := s.client.Exists(key)
+		if err != nil {
+			w.error("ERR " + err.Error())
+			return
+		}
+		if ok {
+			count++
+		}
+	}
+	w.integer(count)
+}
+
+func (s *RESPServer) keys(w *respWriter, args []string) {
+	if len(args) != 1 {
+		w.wrongArgs("keys")
+		return
+	}
+
+	keys, err := s.client.Keys()
+	if err != nil {
+		w.error("ERR " + err.Error())
+		return
+	}
+	w.strings(filterKeys(keys, args[0]))
+}
+
+// scan pages through the key list. The cursor is an offset into it.
+func (s *RESPServer) scan(w *respWriter, args []string) {
+	if len(args) == 0 {
+		w.wrongArgs("scan")
+		return
+	}
+	cursor, err := strconv.Atoi(args[0])
+	if err != nil || cursor < 0 {
+		w.error("ERR invalid cursor")
+		return
+	}
+
+	match, count := "*", 10
+	for i := 1; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			w.error("ERR syntax error")
+			return
+		}
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			match = args[i+1]
+		case "COUNT":
+			count, err = strconv.Atoi(args[i+1])
+			if err != nil || count < 1 {
+				w.error("ERR value is not an integer or out of range")
+				return
+			}
+		default:
+			w.error("ERR syntax error")
+			return
+		}
+	}
+
+	keys, err := s.client.Keys()
+	if err != nil {
+		w.error("ERR " + err.Error())
+		return
+	}
+
+	end := min(cursor+count, len(keys))
+	next := end
+	if end >= len(keys) {
+		next = 0
+	}
+
+	var page []string
+	for i := min(cursor, len(keys)); i < end; i++ {
+		if globMatch(match, keys[i]) {
+			page = append(page, keys[i])
+		}
+	}
+
+	w.array(2)
+	w.bulk(strconv.Itoa(next))
+	w.strings(page)
+}
+
+func (s *RESPServer) append(w *respWriter, args []string) {
+	if len(args) != 2 && len(args) != 4 {
+		w.wrongArgs("append")
+		return
+	}
+	key, value := args[0], args[1]
+
+	var parentPath []int
+	if len(args) == 4 {
+		if strings.ToUpper(args[2]) != "PARENT" {
+			w.error("ERR syntax error")
+			return
+		}
+		var err error
+		parentPath, err = redditkv.ParsePath(args[3])
+		if err != nil {
+			w.error("ERR " + err.Error())
+			return
+		}
+	}
+
+	err := s.client.Append(key, value, parentPath)
+	var notFound *redditkv.KeyNotFoundError
+	if errors.As(err, &notFound) && parentPath == nil {
+		// Like Redis, APPEND on a missing key creates it
+		err = s.client.Set(key, value)
+	}
+	if err != nil {
+		w.error("ERR " + err.Error())
+		return
+	}
+
+	// Redis replies with the value's new length; a tree's length is the
+	// number of values in it
+	tree, err := s.client.Get(key)
+	if err != nil {
+		w.error("ERR " + err.Error())
+		return
+	}
+	w.integer(int64(countValues(tree)))
+}
+
+func countValues(node *redditkv.ValueNode) int {
+	n := 1
+	for i := range node.Children {
+		n += countValues(&node.Children[i])
+	}
+	return n
+}
+
+// nullOrError replies with a null for missing keys and an error otherwise.
+func (s *RESPServer) nullOrError(w *respWriter, err error) {
+	var notFound *redditkv.KeyNotFoundError
+	if errors.As(err, &notFound) {
+		w.null()
+		return
+	}
+	w.error("ERR " + err.Error())
+}
+
+// Limits on what a client may send or announce before sending it, so a
+// single line or header can't make the server allocate unbounded memory.
+// The multibulk limit is Redis's own. Lines hold inline commands, which
+// may carry a value, and headers; memcached reads its lines the same way.
+const (
+	maxMultibulkLength = 1024 * 1024
+	maxBulkLength      = maxValueSize
+	maxLineLength      = maxValueSize + 1024
+)
+
+var errLineTooLong = errors.New("line too long")
+
+// readCommand reads either a RESP array of bulk strings or an inline
+// command (a plain line of space-separated words, as typed into telnet).
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, nil
+	}
+	if line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 || n > maxMultibulkLength {
+		return nil, fmt.Errorf("invalid multibulk length")
+	}
+
+	// n is still the client's word; grow args as arguments actually arrive
+	args := make([]string, 0, min(n, 16))
+	for range n {
+		header, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(header) == 0 || header[0] != '$' {
+			return nil, fmt.Errorf("expected '$', got '%s'", header)
+		}
+		size, err := strconv.Atoi(header[1:])
+		if err != nil || size < 0 || size > maxBulkLength {
+			return nil, fmt.Errorf("invalid bulk length")
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+// readLine reads a line of at most maxLineLength bytes.
+func readLine(r *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if len(line)+len(chunk) > maxLineLength {
+			return "", errLineTooLong
+		}
+		line = append(line, chunk...)
+		if err == nil {
+			return strings.TrimRight(string(line), "\r\n"), nil
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return "", err
+		}
+	}
+}
+
+// oneLine replaces line breaks in an error message, which would
+// otherwise end the reply early and let the rest pass for another one.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// respWriter encodes replies for the connection's negotiated protocol.
+type respWriter struct {
+	w     *bufio.Writer
+	proto int
+}
+
+func (w *respWriter) simple(s string) {
+	fmt.Fprintf(w.w, "+%s\r\n", s)
+}
+
+func (w *respWriter) error(s string) {
+	fmt.Fprintf(w.w, "-%s\r\n", oneLine(s))
+}
+
+func (w *respWriter) wrongArgs(name string) {
+	w.error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+}
+
+func (w *respWriter) integer(n int64) {
+	fmt.Fprintf(w.w, ":%d\r\n", n)
+}
+
+func (w *respWriter) bulk(s string) {
+	fmt.Fprintf(w.w, "$%d\r\n%s\r\n", len(s), s)
+}
+
+func (w *respWriter) null() {
+	if w.proto == 3 {
+		w.w.WriteString("_\r\n")
+		return
+	}
+	w.w.WriteString("$-1\r\n")
+}
+
+func (w *respWriter) array(n int) {
+	fmt.Fprintf(w.w, "*%d\r\n", n)
+}
+
+func (w *respWriter) mapHeader(n int) {
+	if w.proto == 3 {
+		fmt.Fprintf(w.w, "%%%d\r\n", n)
+		return
+	}
+	w.array(n * 2)
+}
+
+func (w *respWriter) strings(items []string) {
+	w.array(len(items))
+	for _, item := range items {
+		w.bulk(item)
+	}
+}
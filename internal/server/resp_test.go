@@ -0,0 +1,261 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/sprite/reddit-kv/pkg/redditkv"
+)
+
+// testConn is a test client that sends requests and reads raw reply lines.
+type testConn struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// newTestConn connects to serve over an in-memory pipe.
+func newTestConn(t *testing.T, serve func(net.Conn)) *testConn {
+	t.Helper()
+	serverSide, clientSide := net.Pipe()
+	go serve(serverSide)
+	t.Cleanup(func() { clientSide.Close() })
+
+	return &testConn{t: t, conn: clientSide, r: bufio.NewReader(clientSide)}
+}
+
+func newRESPConn(t *testing.T) *testConn {
+	t.Helper()
+	client := redditkv.NewWithAPI(redditkv.NewMockRedditAPI(), "testsubreddit")
+	return newTestConn(t, NewRESPServer(client).ServeConn)
+}
+
+// do sends a command as a RESP array of bulk strings.
+func (c *testConn) do(args ...string) {
+	c.t.Helper()
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	c.send(b.String())
+}
+
+func (c *testConn) send(raw string) {
+	c.t.Helper()
+	if _, err := c.conn.Write([]byte(raw)); err != nil {
+		c.t.Fatalf("write failed: %v", err)
+	}
+}
+
+func (c *testConn) line() string {
+	c.t.Helper()
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		c.t.Fatalf("read failed: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+func (c *testConn) expect(want ...string) {
+	c.t.Helper()
+	for _, w := range want {
+		if got := c.line(); got != w {
+			c.t.Fatalf("Expected reply line %q, got %q", w, got)
+		}
+	}
+}
+
+func TestRESPGetSetDel(t *testing.T) {
+	c := newRESPConn(t)
+
+	c.do("PING")
+	c.expect("+PONG")
+
+	c.do("GET", "greeting")
+	c.expect("$-1")
+
+	c.do("SET", "greeting", "hello")
+	c.expect("+OK")
+
+	c.do("GET", "greeting")
+	c.expect("$5", "hello")
+
+	c.do("EXISTS", "greeting", "missing")
+	c.expect(":1")
+
+	c.do("DEL", "greeting", "missing")
+	c.expect(":1")
+
+	c.do("EXISTS", "greeting")
+	c.expect(":0")
+}
+
+func TestRESPSetOptions(t *testing.T) {
+	c := newRESPConn(t)
+
+	c.do("SET", "k", "v1", "XX")
+	c.expect("$-1")
+
+	c.do("SET", "k", "v1", "NX")
+	c.expect("+OK")
+
+	c.do("SET", "k", "v2", "NX")
+	c.expect("$-1")
+
+	c.do("SET", "k", "v2", "GET")
+	c.expect("$2", "v1")
+
+	c.do("SET", "k", "v3", "EX", "10")
+	c.expect("-ERR expiration is not supported")
+}
+
+func TestRESPKeysAndScan(t *testing.T) {
+	c := newRESPConn(t)
+
+	for _, key := range []string{"user:1", "user:2", "other"} {
+		c.do("SET", key, "x")
+		c.expect("+OK")
+	}
+
+	c.do("KEYS", "user:*")
+	c.expect("*2")
+	_ = c.line()
+	_ = c.line()
+	_ = c.line()
+	_ = c.line()
+
+	c.do("SCAN", "0", "COUNT", "2")
+	c.expect("*2", "$1", "2", "*2")
+	_ = c.line()
+	_ = c.line()
+	_ = c.line()
+	_ = c.line()
+
+	c.do("SCAN", "2", "COUNT", "2")
+	c.expect("*2", "$1", "0", "*1")
+}
+
+func TestRESPKeysGlob(t *testing.T) {
+	c := newRESPConn(t)
+
+	for _, key := range []string{"a/b/c", "a/x", "b"} {
+		c.do("SET", key, "x")
+		c.expect("+OK")
+	}
+
+	// Unlike path.Match, * crosses slashes
+	c.do("KEYS", "a*")
+	c.expect("*2")
+	_ = c.line()
+	_ = c.line()
+	_ = c.line()
+	_ = c.line()
+
+	c.do("SCAN", "0", "MATCH", "*/c", "COUNT", "10")
+	c.expect("*2", "$1", "0", "*1", "$5", "a/b/c")
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "a/b", true},
+		{"user:*", "user:1", true},
+		{"user:?", "user:12", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"[", "x", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestRESPAppend(t *testing.T) {
+	c := newRESPConn(t)
+
+	c.do("APPEND", "tree", "root")
+	c.expect(":1")
+
+	c.do("APPEND", "tree", "child", "PARENT", "0")
+	c.expect(":2")
+
+	c.do("GETTREE", "tree")
+	if header := c.line(); !strings.HasPrefix(header, "$") {
+		t.Fatalf("Expected bulk string, got %q", header)
+	}
+	want := `{"value":"root","children":[{"value":"child","children":[]}]`
+	if body := c.line(); !strings.HasPrefix(body, want) {
+		t.Errorf("Expected tree %s, got %s", want, body)
+	}
+}
+
+func TestRESPUnknownCommand(t *testing.T) {
+	c := newRESPConn(t)
+
+	c.do("FLUSHALL")
+	c.expect("-ERR unknown command 'flushall'")
+
+	c.do("HELLO", "3")
+	c.expect("%3", "$6", "server", "$9", "reddit-kv", "$5", "proto", ":3", "$4", "mode", "$10", "standalone")
+
+	c.do("GET", "missing")
+	c.expect("_")
+}
+
+// brokenClient returns a client whose every Reddit call fails with a
+// message spanning several lines.
+func brokenClient() *redditkv.KVClient {
+	return redditkv.NewWithAPI(redditkv.NewMockRedditAPI(), "testsubreddit",
+		redditkv.WithMiddleware(func(next redditkv.Invoker) redditkv.Invoker {
+			return func(ctx context.Context, call *redditkv.Call) (any, error) {
+				return nil, errors.New("boom\r\n+OK")
+			}
+		}))
+}
+
+func TestRESPErrorsStayOnOneLine(t *testing.T) {
+	c := newTestConn(t, NewRESPServer(brokenClient()).ServeConn)
+
+	c.do("GET", "k")
+	if got := c.line(); !strings.HasPrefix(got, "-ERR ") || !strings.HasSuffix(got, "boom  +OK") {
+		t.Errorf("Expected a single error line, got %q", got)
+	}
+	c.do("PING")
+	c.expect("+PONG")
+}
+
+func TestRESPLineTooLong(t *testing.T) {
+	c := newRESPConn(t)
+
+	// The server stops reading partway, so write from the side
+	go c.conn.Write([]byte(strings.Repeat("a", 2*maxLineLength)))
+	c.expect("-ERR Protocol error: line too long")
+}
+
+func TestRESPOversizedHeaders(t *testing.T) {
+	for _, header := range []string{"*2000000000\r\n", "*1\r\n$2000000000\r\n"} {
+		c := newRESPConn(t)
+		c.send(header)
+		if got := c.line(); !strings.HasPrefix(got, "-ERR Protocol error: invalid") {
+			t.Errorf("Expected a protocol error for %q, got %q", header, got)
+		}
+		if _, err := c.r.ReadString('\n'); err == nil {
+			t.Errorf("Expected the connection to close after %q", header)
+		}
+	}
+}
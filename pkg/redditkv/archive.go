@@ -0,0 +1,113 @@
+package redditkv
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// Bodies Reddit substitutes for comments that were removed by a moderator
+// or deleted by their author.
+const (
+	removedBody = "[removed]"
+	deletedBody = "[deleted]"
+)
+
+// isRemovedBody reports whether a comment body is a removal placeholder.
+func isRemovedBody(body string) bool {
+	return body == removedBody || body == deletedBody
+}
+
+// isRemovedComment reports whether a comment was removed or deleted.
+// Reddit blanks the author of such comments too, which tells them apart
+// from values that happen to be the literal placeholder text.
+func isRemovedComment(comment *reddit.Comment) bool {
+	return isRemovedBody(comment.Body) && comment.Author == deletedBody
+}
+
+// checkWritable returns a typed error if new comments can't be added to the
+// post. Listings don't say whether a post is archived (subreddits can turn
+// archiving off, too), so that only shows up as a TOO_OLD error from the
+// write itself; see classifyWriteError.
+func checkWritable(key string, post *reddit.Post) error {
+	if post == nil {
+		return nil
+	}
+	if post.Locked {
+		return &LockedKeyError{Key: key}
+	}
+	return nil
+}
+
+// classifyWriteError maps Reddit's error labels for archived and locked
+// threads to typed errors. It returns nil if err is something else.
+func classifyWriteError(key string, err error) error {
+	var jsonErr *reddit.JSONErrorResponse
+	if !errors.As(err, &jsonErr) {
+		return nil
+	}
+	for _, apiErr := range jsonErr.JSON.Errors {
+		switch apiErr.Label {
+		case "TOO_OLD":
+			return &ArchivedKeyError{Key: key}
+		case "THREAD_LOCKED":
+			return &LockedKeyError{Key: key}
+		}
+	}
+	return nil
+}
+
+// findRemovedComment returns the path of the first removed or deleted
+// comment in the tree, or nil if there is none.
+func findRemovedComment(comments []*reddit.Comment) []int {
+	for i, comment := range comments {
+		if isRemovedComment(comment) {
+			return []int{i}
+		}
+		if sub := findRemovedComment(comment.Replies.Comments); sub != nil {
+			return append([]int{i}, sub...)
+		}
+	}
+	return nil
+}
+
+// migrate reposts an archived key's comment tree to a fresh post and
+// deletes the old one. Comment order is preserved, so paths stay valid.
+func (c *KVClient) migrate(key string, old *reddit.PostAndComments) (*reddit.PostAndComments, error) {
+	submitted, err := c.api.SubmitPost(c.ctx, c.subreddit, key, old.Post.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create post: %w", err)
+	}
+
+	if err := c.copyComments(submitted.FullID, old.Comments); err != nil {
+		// Don't leave a partial copy behind to shadow the archived post
+		_ = c.api.DeletePost(c.ctx, submitted.ID)
+		return nil, err
+	}
+
+	if err := c.api.DeletePost(c.ctx, old.Post.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete archived post: %w", err)
+	}
+
+	return c.api.GetPost(c.ctx, submitted.ID)
+}
+
+// copyComments recreates a comment tree under the given parent. Removed
+// and deleted comments are left behind rather than copied as placeholder
+// text, along with their replies.
+func (c *KVClient) copyComments(parentID string, comments []*reddit.Comment) error {
+	for _, comment := range comments {
+		if isRemovedComment(comment) {
+			continue
+		}
+		created, err := c.api.SubmitComment(c.ctx, parentID, comment.Body)
+		if err != nil {
+			return fmt.Errorf("failed to copy comment: %w", err)
+		}
+		if err := c.copyComments(created.FullID, comment.Replies.Comments); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package redditkv
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestAppendArchivedKey(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	if err := client.Set("mykey", "root"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	post, _ := client.findPostByTitle("mykey")
+	mock.SetPostArchived(post.ID, true)
+
+	err := client.Append("mykey", "value", nil)
+	var archived *ArchivedKeyError
+	if !errors.As(err, &archived) {
+		t.Fatalf("Expected ArchivedKeyError, got %T: %v", err, err)
+	}
+	if archived.Key != "mykey" {
+		t.Errorf("Expected key 'mykey', got '%s'", archived.Key)
+	}
+}
+
+func TestAppendLockedKey(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	if err := client.Set("mykey", "root"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	post, _ := client.findPostByTitle("mykey")
+	mock.SetPostLocked(post.ID, true)
+
+	err := client.Append("mykey", "value", nil)
+	if _, ok := err.(*LockedKeyError); !ok {
+		t.Fatalf("Expected LockedKeyError, got %T: %v", err, err)
+	}
+}
+
+func TestAppendAutoMigrate(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit", WithAutoMigrate())
+
+	_ = client.Set("mykey", "root")
+	_ = client.Append("mykey", "child", []int{0})
+	post, _ := client.findPostByTitle("mykey")
+	mock.SetPostArchived(post.ID, true)
+
+	// Paths into the old tree should still resolve after migration
+	if err := client.Append("mykey", "grandchild", []int{0, 0}); err != nil {
+		t.Fatalf("Append with auto-migrate failed: %v", err)
+	}
+
+	if mock.GetPostCount() != 1 {
+		t.Errorf("Expected 1 post after migration, got %d", mock.GetPostCount())
+	}
+
+	migrated, _ := client.findPostByTitle("mykey")
+	if migrated.ID == post.ID {
+		t.Error("Expected key to move to a new post")
+	}
+
+	value, err := client.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(value.Children) != 1 || len(value.Children[0].Children) != 1 {
+		t.Fatalf("Unexpected tree after migration: %+v", value)
+	}
+	if value.Children[0].Children[0].Value != "grandchild" {
+		t.Errorf("Expected 'grandchild', got '%s'", value.Children[0].Children[0].Value)
+	}
+}
+
+func TestAutoMigrateSkipsRemoved(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit", WithAutoMigrate())
+
+	_ = client.Set("mykey", "root")
+	_ = client.Append("mykey", "gone", nil)
+	_ = client.Append("mykey", "kept", nil)
+	post, _ := client.findPostByTitle("mykey")
+	pc, _ := mock.GetPost(client.ctx, post.ID)
+	for _, comment := range pc.Comments {
+		if comment.Body == "gone" {
+			mock.RemoveComment(comment.ID)
+		}
+	}
+	mock.SetPostArchived(post.ID, true)
+
+	if err := client.Append("mykey", "new", nil); err != nil {
+		t.Fatalf("Append with auto-migrate failed: %v", err)
+	}
+
+	migrated, _ := client.findPostByTitle("mykey")
+	pc, _ = mock.GetPost(client.ctx, migrated.ID)
+	var bodies []string
+	for _, comment := range pc.Comments {
+		if isRemovedBody(comment.Body) {
+			t.Errorf("Expected removed comments to be left behind, got %q", comment.Body)
+		}
+		bodies = append(bodies, comment.Body)
+	}
+	if !slices.Contains(bodies, "kept") || !slices.Contains(bodies, "new") || slices.Contains(bodies, "gone") {
+		t.Errorf("Unexpected comments after migration: %q", bodies)
+	}
+}
+
+func TestAutoMigrateFailure(t *testing.T) {
+	mock := NewMockRedditAPI()
+	_ = NewWithAPI(mock, "testsubreddit").Set("mykey", "root")
+	post, _ := NewWithAPI(mock, "testsubreddit").findPostByTitle("mykey")
+	mock.SetPostArchived(post.ID, true)
+
+	// The append itself is rejected as archived; copying the tree fails
+	comments := 0
+	broken := func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (any, error) {
+			if call.Op == "SubmitComment" {
+				if comments++; comments == 2 {
+					return nil, errors.New("copy failed")
+				}
+			}
+			return next(ctx, call)
+		}
+	}
+	client := NewWithAPI(mock, "testsubreddit", WithAutoMigrate(), WithMiddleware(broken))
+
+	if err := client.Append("mykey", "value", nil); err == nil {
+		t.Fatal("Expected the migration to fail")
+	}
+	if n := mock.GetPostCount(); n != 1 {
+		t.Errorf("Expected the partial copy to be deleted, got %d posts", n)
+	}
+	current, _ := client.findPostByTitle("mykey")
+	if current == nil || current.ID != post.ID {
+		t.Errorf("Expected the archived post to remain the key, got %+v", current)
+	}
+}
+
+func TestGetRemovedValue(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	_ = client.Set("mykey", "root")
+	_ = client.Append("mykey", "child", []int{0})
+
+	post, _ := client.findPostByTitle("mykey")
+	pc, _ := mock.GetPost(client.ctx, post.ID)
+	mock.RemoveComment(pc.Comments[0].Replies.Comments[0].ID)
+
+	_, err := client.Get("mykey")
+	removed, ok := err.(*RemovedValueError)
+	if !ok {
+		t.Fatalf("Expected RemovedValueError, got %T: %v", err, err)
+	}
+	if len(removed.Path) != 2 || removed.Path[0] != 0 || removed.Path[1] != 0 {
+		t.Errorf("Expected path [0 0], got %v", removed.Path)
+	}
+}
+
+func TestGetPlaceholderValues(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit")
+
+	_ = client.Set("mykey", "[deleted]")
+	_ = client.Append("mykey", "[removed]", []int{0})
+
+	value, err := client.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "[deleted]" || len(value.Children) != 1 || value.Children[0].Value != "[removed]" {
+		t.Errorf("Expected the literal placeholders back, got %+v", value)
+	}
+}
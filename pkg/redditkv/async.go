@@ -0,0 +1,205 @@
+package redditkv
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// defaultAsyncWorkers is the worker count used when AsyncConfig.Workers
+// is unset.
+const defaultAsyncWorkers = 4
+
+// AsyncConfig configures an AsyncClient.
+type AsyncConfig struct {
+	// Workers is how many calls run at once.
+	Workers int
+
+	// OnError, if set, is called with every failed call's error, so
+	// callers that don't wait on their futures still see failures. It
+	// runs on a worker goroutine.
+	OnError func(op, key string, err error)
+}
+
+// Future is the pending result of an asynchronous call.
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// Done returns a channel that is closed once the result is ready.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for the result. If ctx ends first, Wait returns ctx's error
+// and the call carries on in the background.
+func (f *Future[T]) Wait(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// AsyncClient runs a KVClient's calls on a shared pool of workers and
+// returns futures instead of blocking. Calls for the same key run one at
+// a time, in the order they were made; calls for different keys run
+// concurrently, up to the number of workers. Queued calls don't block
+// the caller, however many there are.
+type AsyncClient struct {
+	client  *KVClient
+	onError func(op, key string, err error)
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	keys   map[string][]func() // key -> its calls not yet started; present while any are queued or running
+	ready  []string            // keys with a call to start and none running
+	closed bool
+
+	wg sync.WaitGroup
+}
+
+// NewAsyncClient starts an AsyncClient's workers. Call Close to stop them.
+func NewAsyncClient(c *KVClient, cfg AsyncConfig) *AsyncClient {
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultAsyncWorkers
+	}
+
+	a := &AsyncClient{
+		client:  c,
+		onError: cfg.OnError,
+		keys:    make(map[string][]func()),
+	}
+	a.cond = sync.NewCond(&a.mu)
+
+	for range cfg.Workers {
+		a.wg.Add(1)
+		go a.work()
+	}
+	return a
+}
+
+// Close stops accepting calls, waits for the queued ones to finish and
+// stops the workers. Calls made after Close fail with ErrClosed.
+func (a *AsyncClient) Close() {
+	a.mu.Lock()
+	a.closed = true
+	a.cond.Broadcast()
+	a.mu.Unlock()
+
+	a.wg.Wait()
+}
+
+// SetAsync is the asynchronous form of KVClient.Set.
+func (a *AsyncClient) SetAsync(key, value string) *Future[struct{}] {
+	return submit(a, "Set", key, func() (struct{}, error) {
+		return struct{}{}, a.client.Set(key, value)
+	})
+}
+
+// GetAsync is the asynchronous form of KVClient.Get.
+func (a *AsyncClient) GetAsync(key string) *Future[*ValueNode] {
+	return submit(a, "Get", key, func() (*ValueNode, error) {
+		return a.client.Get(key)
+	})
+}
+
+// AppendAsync is the asynchronous form of KVClient.Append.
+func (a *AsyncClient) AppendAsync(key, value string, parentPath []int) *Future[struct{}] {
+	return submit(a, "Append", key, func() (struct{}, error) {
+		return struct{}{}, a.client.Append(key, value, parentPath)
+	})
+}
+
+// DeleteAsync is the asynchronous form of KVClient.Delete.
+func (a *AsyncClient) DeleteAsync(key string) *Future[struct{}] {
+	return submit(a, "Delete", key, func() (struct{}, error) {
+		return struct{}{}, a.client.Delete(key)
+	})
+}
+
+// ExistsAsync is the asynchronous form of KVClient.Exists.
+func (a *AsyncClient) ExistsAsync(key string) *Future[bool] {
+	return submit(a, "Exists", key, func() (bool, error) {
+		return a.client.Exists(key)
+	})
+}
+
+// submit queues fn to run after the key's earlier calls.
+func submit[T any](a *AsyncClient, op, key string, fn func() (T, error)) *Future[T] {
+	f := &Future[T]{done: make(chan struct{})}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		f.err = ErrClosed
+		close(f.done)
+		return f
+	}
+
+	call := func() {
+		defer close(f.done)
+		defer func() {
+			if r := recover(); r != nil {
+				f.err = fmt.Errorf("%s of key %s panicked: %v", op, key, r)
+			}
+			if f.err != nil && a.onError != nil {
+				a.onError(op, key, f.err)
+			}
+		}()
+		f.value, f.err = fn()
+	}
+
+	// A key with calls queued or running is already on its way through
+	// ready; its worker picks this call up after the earlier ones
+	if calls, busy := a.keys[key]; busy {
+		a.keys[key] = append(calls, call)
+		return f
+	}
+	a.keys[key] = []func(){call}
+	a.ready = append(a.ready, key)
+	a.cond.Signal()
+
+	return f
+}
+
+// work runs queued calls until the client is closed and nothing is left.
+// A worker takes one call of a ready key at a time and puts the key back
+// at the end of ready if it has more, so no worker ever waits on another
+// and busy keys take turns with the rest.
+func (a *AsyncClient) work() {
+	defer a.wg.Done()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for {
+		for len(a.ready) == 0 && !a.closed {
+			a.cond.Wait()
+		}
+		if len(a.ready) == 0 {
+			return
+		}
+		key := a.ready[0]
+		a.ready = a.ready[1:]
+		calls := a.keys[key]
+		call := calls[0]
+		calls[0] = nil
+		a.keys[key] = calls[1:]
+		a.mu.Unlock()
+
+		call()
+
+		a.mu.Lock()
+		if len(a.keys[key]) == 0 {
+			delete(a.keys, key)
+		} else {
+			a.ready = append(a.ready, key)
+			a.cond.Signal()
+		}
+	}
+}
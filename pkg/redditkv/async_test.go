@@ -0,0 +1,172 @@
+package redditkv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAsyncClient(t *testing.T) {
+	async := NewAsyncClient(NewWithAPI(NewMockRedditAPI(), "testsubreddit"), AsyncConfig{})
+	defer async.Close()
+	ctx := context.Background()
+
+	// Calls for one key run in order, without waiting in between
+	async.SetAsync("mykey", "first")
+	async.SetAsync("mykey", "second")
+	async.AppendAsync("mykey", "child", []int{0})
+	get := async.GetAsync("mykey")
+
+	value, err := get.Wait(ctx)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "second" || len(value.Children) != 1 {
+		t.Errorf("Expected 'second' with one child, got %+v", value)
+	}
+
+	if _, err := async.DeleteAsync("mykey").Wait(ctx); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if exists, _ := async.ExistsAsync("mykey").Wait(ctx); exists {
+		t.Error("Expected key to be deleted")
+	}
+
+	var notFound *KeyNotFoundError
+	if _, err := async.GetAsync("missing").Wait(ctx); !errors.As(err, &notFound) {
+		t.Errorf("Expected KeyNotFoundError, got %v", err)
+	}
+}
+
+func TestAsyncClientWorkers(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	track := func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (any, error) {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+			defer func() {
+				mu.Lock()
+				running--
+				mu.Unlock()
+			}()
+			return next(ctx, call)
+		}
+	}
+
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit", WithMiddleware(track))
+	async := NewAsyncClient(client, AsyncConfig{Workers: 3})
+
+	var futures []*Future[struct{}]
+	for i := range 10 {
+		futures = append(futures, async.SetAsync(fmt.Sprintf("key%d", i), "value"))
+	}
+	async.Close()
+
+	for _, f := range futures {
+		select {
+		case <-f.Done():
+		default:
+			t.Fatal("Expected Close to wait for queued calls")
+		}
+	}
+	if peak > 3 {
+		t.Errorf("Expected at most 3 calls at once, got %d", peak)
+	}
+	if _, err := async.SetAsync("late", "value").Wait(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestAsyncClientErrors(t *testing.T) {
+	release := make(chan struct{})
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit", WithMiddleware(gated(release)))
+
+	var mu sync.Mutex
+	var failed []string
+	async := NewAsyncClient(client, AsyncConfig{OnError: func(op, key string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, op+" "+key)
+	}})
+
+	// Wait gives up on a slow call without cancelling it
+	del := async.DeleteAsync("missing")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := del.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+
+	close(release)
+	async.Close()
+
+	if _, err := del.Wait(context.Background()); err == nil {
+		t.Error("Expected Delete of a missing key to fail")
+	}
+	if len(failed) != 1 || failed[0] != "Delete missing" {
+		t.Errorf("Expected OnError for the Delete, got %v", failed)
+	}
+}
+
+func TestAsyncClientSlowKey(t *testing.T) {
+	async := NewAsyncClient(NewWithAPI(NewMockRedditAPI(), "testsubreddit"), AsyncConfig{Workers: 2})
+	defer async.Close()
+
+	// With the slow key's second call queued, the other worker must
+	// still be free for other keys
+	release := make(chan struct{})
+	slow := submit(async, "Test", "slow", func() (int, error) {
+		<-release
+		return 1, nil
+	})
+	after := submit(async, "Test", "slow", func() (int, error) { return 2, nil })
+	other := submit(async, "Test", "other", func() (int, error) { return 3, nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := other.Wait(ctx); err != nil {
+		t.Fatalf("Expected the other key not to wait for the slow one: %v", err)
+	}
+	select {
+	case <-after.Done():
+		t.Error("Expected the slow key's calls to stay in order")
+	default:
+	}
+
+	close(release)
+	if v, _ := slow.Wait(context.Background()); v != 1 {
+		t.Errorf("Expected 1, got %d", v)
+	}
+	if v, _ := after.Wait(context.Background()); v != 2 {
+		t.Errorf("Expected 2, got %d", v)
+	}
+}
+
+func TestAsyncClientPanic(t *testing.T) {
+	var reported error
+	async := NewAsyncClient(NewWithAPI(NewMockRedditAPI(), "testsubreddit"), AsyncConfig{
+		OnError: func(op, key string, err error) { reported = err },
+	})
+	defer async.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	failed := submit(async, "Test", "mykey", func() (int, error) { panic("boom") })
+	if _, err := failed.Wait(ctx); err == nil || err != reported {
+		t.Errorf("Expected the panic as the call's error, got %v (reported %v)", err, reported)
+	}
+
+	// The key isn't stuck behind the call that panicked
+	if _, err := async.SetAsync("mykey", "value").Wait(ctx); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package redditkv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// localBackendPrefix selects a LocalRedditAPI in Config.Backend.
+const localBackendPrefix = "local:"
+
+// NewAPI creates the RedditAPI selected by cfg.Backend: real Reddit when
+// it is empty or "reddit", or a local database file for "local:<path>".
+// cfg.Replay replaces the backend with a cassette, and cfg.Record records
+// the backend's traffic to one.
+func NewAPI(cfg Config) (RedditAPI, error) {
+	if cfg.Replay != "" {
+		return NewReplayRedditAPI(cfg.Replay)
+	}
+
+	api, err := newBackend(cfg)
+	if err != nil || cfg.Record == "" {
+		return api, err
+	}
+	return NewRecordingRedditAPI(api, cfg.Record)
+}
+
+func newBackend(cfg Config) (RedditAPI, error) {
+	switch {
+	case cfg.Backend == "" || cfg.Backend == "reddit":
+		return NewRedditAPI(cfg)
+	case strings.HasPrefix(cfg.Backend, localBackendPrefix):
+		path := strings.TrimPrefix(cfg.Backend, localBackendPrefix)
+		if path == "" {
+			return nil, fmt.Errorf("local backend needs a path, e.g. local:/tmp/kv.json")
+		}
+		return NewLocalRedditAPI(path)
+	}
+	return nil, fmt.Errorf("unknown backend: %s", cfg.Backend)
+}
+
+// isLocalBackend reports whether cfg talks to a local database or a
+// cassette, neither of which has a search index to wait for.
+func isLocalBackend(cfg Config) bool {
+	return strings.HasPrefix(cfg.Backend, localBackendPrefix) || cfg.Replay != ""
+}
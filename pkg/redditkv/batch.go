@@ -0,0 +1,273 @@
+package redditkv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// defaultConcurrency is how many keys MGet, MSet and MDelete work on at
+// once unless WithConcurrency says otherwise.
+const defaultConcurrency = 4
+
+// maxPostsPerLookup is the most posts Reddit returns for one by_id request.
+const maxPostsPerLookup = 100
+
+// KeyResult is the outcome of a multi-key operation for one key.
+type KeyResult struct {
+	Key string
+	// Value is the key's value tree. Only MGet sets it.
+	Value *ValueNode
+	// Err is the key's error, e.g. a KeyNotFoundError from MGet.
+	Err error
+}
+
+// WithConcurrency sets how many keys MGet, MSet and MDelete work on at
+// once. Values below 1 mean one at a time.
+func WithConcurrency(n int) Option {
+	return func(c *KVClient) {
+		c.concurrency = max(n, 1)
+	}
+}
+
+// MGet gets several keys. The results are in the same order as keys.
+//
+// With a cache enabled, keys whose cached post ID is still trusted are
+// revalidated together with one lookup per 100 keys instead of one
+// request each. The other keys are looked for among the subreddit's
+// newest posts, one listing page per 100 keys, which saves searching for
+// each of them; keys not found there are fetched as Get does.
+func (c *KVClient) MGet(keys []string) []KeyResult {
+	cached := c.revalidate(keys)
+	located := c.locate(keys, cached)
+
+	return c.forEach(keys, func(i int, key string, res *KeyResult) error {
+		if value, ok := cached[i]; ok {
+			res.Value = value
+			return nil
+		}
+		var (
+			value *ValueNode
+			err   error
+		)
+		if post, ok := located[i]; ok {
+			value, err = c.getPost(key, post.ID)
+		} else {
+			value, err = c.Get(key)
+		}
+		res.Value = value
+		return err
+	})
+}
+
+// MSet sets several keys to scalar values, as Set does. The results are
+// sorted by key.
+func (c *KVClient) MSet(values map[string]string) []KeyResult {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return c.forEach(keys, func(i int, key string, res *KeyResult) error {
+		return c.Set(key, values[key])
+	})
+}
+
+// MDelete deletes several keys. The results are in the same order as keys.
+func (c *KVClient) MDelete(keys []string) []KeyResult {
+	return c.forEach(keys, func(i int, key string, res *KeyResult) error {
+		return c.Delete(key)
+	})
+}
+
+// forEach runs fn for each key on a bounded pool of workers and collects
+// the results. Once a call hits Reddit's rate limit, no worker starts
+// another key until the limit resets.
+func (c *KVClient) forEach(keys []string, fn func(i int, key string, res *KeyResult) error) []KeyResult {
+	results := make([]KeyResult, len(keys))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(c.workers(), len(keys)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				c.rate.wait(c.ctx)
+				res := &results[i]
+				res.Key = keys[i]
+				res.Err = fn(i, keys[i], res)
+				c.rate.observe(res.Err)
+			}
+		}()
+	}
+
+	for i := range keys {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return results
+}
+
+func (c *KVClient) workers() int {
+	if c.concurrency == 0 {
+		return defaultConcurrency
+	}
+	return c.concurrency
+}
+
+// revalidate serves what it can of keys from the cache without a request
+// per key. Fresh values are returned as they are; expired values with a
+// trusted post ID are checked with batched post lookups and kept if the
+// post, its comment count and its edit time are unchanged and the value
+// isn't older than MaxAge. The result maps indexes in
+// keys to values.
+func (c *KVClient) revalidate(keys []string) map[int]*ValueNode {
+	found := make(map[int]*ValueNode)
+	if c.cache == nil {
+		return found
+	}
+
+	now := time.Now()
+	stale := make(map[int]*cacheEntry)
+	var postIDs []string
+	for i, key := range keys {
+		entry, _ := c.cache.lookup(key)
+		switch {
+		case entry == nil:
+		case now.Before(entry.ValueExpires):
+			c.cache.count(&c.cache.stats.Hits)
+			found[i] = cloneNode(entry.Value)
+		case now.Before(entry.PostExpires):
+			stale[i] = entry
+			postIDs = append(postIDs, entry.PostID)
+		}
+	}
+
+	posts := make(map[string]*reddit.Post)
+	for chunk := range slices.Chunk(postIDs, maxPostsPerLookup) {
+		batch, err := c.api.GetPosts(c.ctx, chunk)
+		if err != nil {
+			// The keys left over are fetched one by one
+			c.rate.observe(err)
+			break
+		}
+		for _, post := range batch {
+			posts[post.ID] = post
+		}
+	}
+
+	for i, entry := range stale {
+		post := posts[entry.PostID]
+		// Reddit still returns deleted posts, with their body blanked
+		if post == nil || isRemovedBody(post.Body) || !c.cache.current(entry, post, now) {
+			continue
+		}
+		c.cache.renew(keys[i], entry)
+		c.cache.count(&c.cache.stats.Revalidations)
+		found[i] = cloneNode(entry.Value)
+	}
+	return found
+}
+
+// locate finds the posts of the keys not in found among the subreddit's
+// newest posts, reading one listing page per 100 keys at most. Like a
+// search, it picks the newest post of a key. The result maps indexes in
+// keys to posts.
+func (c *KVClient) locate(keys []string, found map[int]*ValueNode) map[int]*reddit.Post {
+	wanted := make(map[string][]int)
+	for i, key := range keys {
+		if _, ok := found[i]; !ok {
+			wanted[key] = append(wanted[key], i)
+		}
+	}
+
+	located := make(map[int]*reddit.Post)
+	pages := (len(wanted) + maxPostsPerLookup - 1) / maxPostsPerLookup
+	opts := &reddit.ListOptions{Limit: maxPostsPerLookup}
+	for range pages {
+		page, err := c.api.ListNewPosts(c.ctx, c.subreddit, opts)
+		if err != nil {
+			// The keys left over are fetched one by one
+			c.rate.observe(err)
+			break
+		}
+		for _, post := range page {
+			indexes, ok := wanted[post.Title]
+			if !ok || isRemovedBody(post.Body) {
+				continue
+			}
+			for _, i := range indexes {
+				located[i] = post
+			}
+			delete(wanted, post.Title)
+		}
+		if len(wanted) == 0 || len(page) < maxPostsPerLookup {
+			break
+		}
+		opts = &reddit.ListOptions{Limit: maxPostsPerLookup, After: page[len(page)-1].FullID}
+	}
+	return located
+}
+
+// getPost gets a key whose post is already known, caching the value if a
+// cache is enabled.
+func (c *KVClient) getPost(key, postID string) (*ValueNode, error) {
+	var gen uint64
+	if c.cache != nil {
+		_, gen = c.cache.lookup(key)
+	}
+
+	postAndComments, err := c.api.GetPost(c.ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	if c.cache != nil {
+		return c.fill(gen, key, postAndComments)
+	}
+	return valueFromPost(key, postAndComments)
+}
+
+// rateGate holds back new work after a call hit Reddit's rate limit.
+type rateGate struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// wait blocks until the last rate limit seen has reset.
+func (g *rateGate) wait(ctx context.Context) {
+	g.mu.Lock()
+	delay := time.Until(g.until)
+	g.mu.Unlock()
+
+	if delay <= 0 {
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(delay):
+	}
+}
+
+// observe notes the reset time if err is a rate limit error.
+func (g *rateGate) observe(err error) {
+	var rateErr *reddit.RateLimitError
+	if !errors.As(err, &rateErr) {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if rateErr.Rate.Reset.After(g.until) {
+		g.until = rateErr.Rate.Reset
+	}
+}
@@ -0,0 +1,184 @@
+package redditkv
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+func TestMGet(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit")
+	_ = client.Set("a", "first")
+	_ = client.Set("b", "second")
+
+	results := client.MGet([]string{"a", "missing", "b"})
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[0].Key != "a" || results[0].Err != nil || results[0].Value.Value != "first" {
+		t.Errorf("Unexpected result for 'a': %+v", results[0])
+	}
+	if results[2].Key != "b" || results[2].Err != nil || results[2].Value.Value != "second" {
+		t.Errorf("Unexpected result for 'b': %+v", results[2])
+	}
+
+	var notFound *KeyNotFoundError
+	if !errors.As(results[1].Err, &notFound) {
+		t.Errorf("Expected KeyNotFoundError, got %v", results[1].Err)
+	}
+}
+
+func TestMSetAndMDelete(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit")
+
+	results := client.MSet(map[string]string{"b": "2", "a": "1", "c": "3"})
+	for i, key := range []string{"a", "b", "c"} {
+		if results[i].Key != key || results[i].Err != nil {
+			t.Errorf("Unexpected result %d: %+v", i, results[i])
+		}
+	}
+	if value, _ := client.Get("b"); value.Value != "2" {
+		t.Errorf("Expected '2', got '%s'", value.Value)
+	}
+
+	results = client.MDelete([]string{"a", "missing", "c"})
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Errorf("MDelete failed: %+v", results)
+	}
+	var notFound *KeyNotFoundError
+	if !errors.As(results[1].Err, &notFound) {
+		t.Errorf("Expected KeyNotFoundError, got %v", results[1].Err)
+	}
+
+	keys, _ := client.Keys()
+	if len(keys) != 1 || keys[0] != "b" {
+		t.Errorf("Expected only 'b' left, got %v", keys)
+	}
+}
+
+func TestMGetBatchedLookup(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+	_ = client.MSet(map[string]string{"a": "1", "b": "2", "c": "3"})
+	_ = client.Set("b", "newer")
+
+	// Without a cache, one listing replaces a search per key
+	searches, listings := mock.CallCount("SearchPosts"), mock.CallCount("ListNewPosts")
+	results := client.MGet([]string{"a", "b", "c", "missing"})
+	for _, res := range results[:3] {
+		if res.Err != nil {
+			t.Errorf("Get %s failed: %v", res.Key, res.Err)
+		}
+	}
+	if results[1].Value.Value != "newer" {
+		t.Errorf("Expected the newest post of 'b', got %+v", results[1].Value)
+	}
+	var notFound *KeyNotFoundError
+	if !errors.As(results[3].Err, &notFound) {
+		t.Errorf("Expected KeyNotFoundError, got %v", results[3].Err)
+	}
+	if got := mock.CallCount("ListNewPosts") - listings; got != 1 {
+		t.Errorf("Expected 1 listing, got %d", got)
+	}
+	if got := mock.CallCount("SearchPosts") - searches; got != 1 {
+		t.Errorf("Expected to search only for the missing key, got %d searches", got)
+	}
+}
+
+func TestMGetBatchedRevalidation(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit", WithCache(CacheConfig{PostTTL: time.Hour}))
+	other := NewWithAPI(mock, "testsubreddit")
+
+	keys := []string{"a", "b", "c"}
+	_ = client.MSet(map[string]string{"a": "1", "b": "2", "c": "3"})
+	_ = client.MGet(keys)
+
+	// All three expired values are checked with a single lookup
+	fetches, lookups := mock.CallCount("GetPost"), mock.CallCount("GetPosts")
+	for _, res := range client.MGet(keys) {
+		if res.Err != nil {
+			t.Errorf("Get %s failed: %v", res.Key, res.Err)
+		}
+	}
+	if got := mock.CallCount("GetPosts") - lookups; got != 1 {
+		t.Errorf("Expected 1 batched lookup, got %d", got)
+	}
+	if got := mock.CallCount("GetPost") - fetches; got != 0 {
+		t.Errorf("Expected no post fetches, got %d", got)
+	}
+	if stats := client.CacheStats(); stats.Revalidations != 3 {
+		t.Errorf("Expected 3 revalidations, got %+v", stats)
+	}
+
+	// Only the key another client appended to is refetched
+	_ = other.Append("b", "child", []int{0})
+	fetches = mock.CallCount("GetPost")
+	results := client.MGet(keys)
+	if len(results[1].Value.Children) != 1 {
+		t.Errorf("Expected the other client's append, got %+v", results[1].Value)
+	}
+	if got := mock.CallCount("GetPost") - fetches; got != 1 {
+		t.Errorf("Expected 1 post fetch, got %d", got)
+	}
+}
+
+func TestMGetConcurrencyLimit(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	track := func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (any, error) {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+			defer func() {
+				mu.Lock()
+				running--
+				mu.Unlock()
+			}()
+			return next(ctx, call)
+		}
+	}
+
+	mock := NewMockRedditAPI()
+	_ = NewWithAPI(mock, "testsubreddit").MSet(map[string]string{"a": "1", "b": "2", "c": "3", "d": "4", "e": "5"})
+	client := NewWithAPI(mock, "testsubreddit", WithMiddleware(track), WithConcurrency(2))
+
+	for _, res := range client.MGet([]string{"a", "b", "c", "d", "e"}) {
+		if res.Err != nil {
+			t.Errorf("Get %s failed: %v", res.Key, res.Err)
+		}
+	}
+	if peak > 2 {
+		t.Errorf("Expected at most 2 calls at once, got %d", peak)
+	}
+}
+
+func TestMGetWaitsForRateLimit(t *testing.T) {
+	mock := NewMockRedditAPI()
+	_ = NewWithAPI(mock, "testsubreddit").MSet(map[string]string{"a": "1", "b": "2"})
+
+	reset := time.Now().Add(100 * time.Millisecond)
+	limited, _ := failing("GetPost", 1, &reddit.RateLimitError{Rate: reddit.Rate{Reset: reset}})
+	client := NewWithAPI(mock, "testsubreddit", WithMiddleware(limited), WithConcurrency(1))
+
+	results := client.MGet([]string{"a", "b"})
+
+	var rateErr *reddit.RateLimitError
+	if !errors.As(results[0].Err, &rateErr) {
+		t.Errorf("Expected RateLimitError, got %v", results[0].Err)
+	}
+	if results[1].Err != nil {
+		t.Errorf("Get b failed: %v", results[1].Err)
+	}
+	if time.Now().Before(reset) {
+		t.Error("Expected the second key to wait for the rate limit reset")
+	}
+}
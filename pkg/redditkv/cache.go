@@ -0,0 +1,386 @@
+package redditkv
+
+import (
+	"container/list"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// Defaults for CacheConfig.
+const (
+	defaultCacheSize   = 1024
+	defaultCacheMaxAge = 5 * time.Minute
+)
+
+// CacheConfig configures the read-through cache enabled by WithCache.
+type CacheConfig struct {
+	// Size is the maximum number of keys kept; the least recently used
+	// key is evicted first.
+	Size int
+
+	// ValueTTL is how long a cached value tree is returned without asking
+	// Reddit. After that, Get searches for the key and only refetches the
+	// tree if the post or its comment count changed.
+	ValueTTL time.Duration
+
+	// PostTTL is how long a key's cached post ID is trusted, which saves
+	// the search when the tree does need refetching.
+	PostTTL time.Duration
+
+	// MaxAge caps how long a value tree is served, revalidations
+	// included, after it was fetched. Edits to comments change neither
+	// the post nor its comment count, so they are only seen once a tree
+	// is this old. Zero means five minutes.
+	MaxAge time.Duration
+}
+
+// CacheStats counts how Get requests were served by the cache.
+type CacheStats struct {
+	// Hits were served from the cache without any API calls.
+	Hits uint64 `json:"hits"`
+	// Revalidations were served from the cache after a search showed
+	// the key's post and comment count were unchanged.
+	Revalidations uint64 `json:"revalidations"`
+	// Misses had to fetch the value tree.
+	Misses uint64 `json:"misses"`
+	// Evictions counts keys dropped to stay within the size limit.
+	Evictions uint64 `json:"evictions"`
+	// Size is the number of keys currently cached.
+	Size int `json:"size"`
+}
+
+// WithCache enables an in-process LRU cache for Get. Writes made through
+// this client invalidate the keys they touch, but writes by other clients
+// may go unnoticed for up to the TTLs. Comment edits don't change the
+// comment count, so they are only seen once a key's entry is refetched,
+// at the latest after MaxAge.
+func WithCache(cfg CacheConfig) Option {
+	return func(c *KVClient) {
+		cfg = cfg.withDefaults()
+		c.cache = &readCache{cfg: cfg, entries: newMemoryStore(cfg.Size)}
+	}
+}
+
+func (cfg CacheConfig) withDefaults() CacheConfig {
+	if cfg.Size <= 0 {
+		cfg.Size = defaultCacheSize
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = defaultCacheMaxAge
+	}
+	return cfg
+}
+
+// CacheStats returns the cache's counters. They are all zero if the
+// cache isn't enabled.
+func (c *KVClient) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	return c.cache.snapshot()
+}
+
+// cacheEntry is what's known about one key.
+type cacheEntry struct {
+	PostID       string     `json:"post_id"`
+	NumComments  int        `json:"num_comments"`
+	Value        *ValueNode `json:"value"`
+	ValueExpires time.Time  `json:"value_expires"`
+	PostExpires  time.Time  `json:"post_expires"`
+	// Fetched is when Value was fetched; revalidation doesn't change it.
+	Fetched time.Time `json:"fetched"`
+	// Edited is when the post was last edited, if ever.
+	Edited time.Time `json:"edited,omitempty"`
+}
+
+// cacheStore holds cache entries. Every invalidation bumps a generation
+// number, and store only succeeds if the generation is still the one
+// lookup returned, so a Get that raced with a write doesn't cache what
+// it read before the write finished.
+type cacheStore interface {
+	// lookup returns the key's entry, or nil, and the generation.
+	lookup(key string) (*cacheEntry, uint64)
+	// store saves an entry and returns how many keys were evicted.
+	store(gen uint64, key string, entry *cacheEntry) int
+	// renew updates the expiry times of an existing entry.
+	renew(key string, valueExpires, postExpires time.Time)
+	invalidate(key string)
+	purge()
+	len() int
+}
+
+// readCache implements Get's caching policy on top of a cacheStore and
+// keeps this client's statistics.
+type readCache struct {
+	cfg     CacheConfig
+	entries cacheStore
+
+	mu    sync.Mutex
+	stats CacheStats
+}
+
+func (rc *readCache) lookup(key string) (*cacheEntry, uint64) {
+	return rc.entries.lookup(key)
+}
+
+// store caches a freshly fetched value tree.
+func (rc *readCache) store(gen uint64, key string, post *reddit.Post, value *ValueNode) {
+	now := time.Now()
+	evicted := rc.entries.store(gen, key, &cacheEntry{
+		PostID:       post.ID,
+		NumComments:  post.NumberOfComments,
+		Value:        cloneNode(value),
+		ValueExpires: rc.valueExpires(now, now),
+		PostExpires:  now.Add(rc.cfg.PostTTL),
+		Fetched:      now,
+		Edited:       editedAt(post),
+	})
+	if evicted > 0 {
+		rc.mu.Lock()
+		rc.stats.Evictions += uint64(evicted)
+		rc.mu.Unlock()
+	}
+}
+
+// current reports whether a cached entry still matches the key's post,
+// so its value can be served again without refetching it.
+func (rc *readCache) current(entry *cacheEntry, post *reddit.Post, now time.Time) bool {
+	return entry.PostID == post.ID && entry.NumComments == post.NumberOfComments &&
+		entry.Edited.Equal(editedAt(post)) && now.Before(entry.Fetched.Add(rc.cfg.MaxAge))
+}
+
+// renew marks a key's value as current again after revalidation.
+func (rc *readCache) renew(key string, entry *cacheEntry) {
+	now := time.Now()
+	rc.entries.renew(key, rc.valueExpires(now, entry.Fetched), now.Add(rc.cfg.PostTTL))
+}
+
+// valueExpires is when a value fetched at fetched stops being served
+// without asking Reddit.
+func (rc *readCache) valueExpires(now, fetched time.Time) time.Time {
+	expires := now.Add(rc.cfg.ValueTTL)
+	if limit := fetched.Add(rc.cfg.MaxAge); expires.After(limit) {
+		return limit
+	}
+	return expires
+}
+
+func editedAt(post *reddit.Post) time.Time {
+	if post.Edited == nil {
+		return time.Time{}
+	}
+	return post.Edited.Time
+}
+
+func (rc *readCache) invalidate(key string) {
+	rc.entries.invalidate(key)
+}
+
+func (rc *readCache) purge() {
+	rc.entries.purge()
+}
+
+func (rc *readCache) count(counter *uint64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	*counter++
+}
+
+func (rc *readCache) snapshot() CacheStats {
+	rc.mu.Lock()
+	stats := rc.stats
+	rc.mu.Unlock()
+
+	stats.Size = rc.entries.len()
+	return stats
+}
+
+// memoryStore is an in-process LRU cacheStore. It is safe for
+// concurrent use.
+type memoryStore struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // front is most recently used
+	gen     uint64
+}
+
+// memoryItem is an element of memoryStore.order.
+type memoryItem struct {
+	key   string
+	entry *cacheEntry
+}
+
+func newMemoryStore(size int) *memoryStore {
+	return &memoryStore{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (ms *memoryStore) lookup(key string) (*cacheEntry, uint64) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	elem, ok := ms.entries[key]
+	if !ok {
+		return nil, ms.gen
+	}
+	ms.order.MoveToFront(elem)
+	entry := *elem.Value.(*memoryItem).entry
+	return &entry, ms.gen
+}
+
+func (ms *memoryStore) store(gen uint64, key string, entry *cacheEntry) int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if gen != ms.gen {
+		return 0
+	}
+	if elem, ok := ms.entries[key]; ok {
+		elem.Value.(*memoryItem).entry = entry
+		ms.order.MoveToFront(elem)
+		return 0
+	}
+	ms.entries[key] = ms.order.PushFront(&memoryItem{key: key, entry: entry})
+
+	evicted := 0
+	for ms.order.Len() > ms.size {
+		oldest := ms.order.Back()
+		ms.order.Remove(oldest)
+		delete(ms.entries, oldest.Value.(*memoryItem).key)
+		evicted++
+	}
+	return evicted
+}
+
+func (ms *memoryStore) renew(key string, valueExpires, postExpires time.Time) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if elem, ok := ms.entries[key]; ok {
+		entry := *elem.Value.(*memoryItem).entry
+		entry.ValueExpires = valueExpires
+		entry.PostExpires = postExpires
+		elem.Value.(*memoryItem).entry = &entry
+	}
+}
+
+func (ms *memoryStore) invalidate(key string) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.gen++
+	if elem, ok := ms.entries[key]; ok {
+		ms.order.Remove(elem)
+		delete(ms.entries, key)
+	}
+}
+
+func (ms *memoryStore) purge() {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.gen++
+	ms.entries = make(map[string]*list.Element)
+	ms.order.Init()
+}
+
+func (ms *memoryStore) len() int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.order.Len()
+}
+
+// invalidate is called when a write to key finishes. It drops the key
+// from the cache, if there is one, and keeps later reads from joining
+// reads that started before the write.
+func (c *KVClient) invalidate(key string) {
+	c.writes.Add(1)
+	if c.cache != nil {
+		c.cache.invalidate(key)
+	}
+}
+
+// purge is like invalidate, for writes that may touch any key.
+func (c *KVClient) purge() {
+	c.writes.Add(1)
+	if c.cache != nil {
+		c.cache.purge()
+	}
+}
+
+// cachedGet is Get with the cache enabled.
+func (c *KVClient) cachedGet(key string) (*ValueNode, error) {
+	now := time.Now()
+	entry, gen := c.cache.lookup(key)
+	if entry != nil && now.Before(entry.ValueExpires) {
+		c.cache.count(&c.cache.stats.Hits)
+		return cloneNode(entry.Value), nil
+	}
+
+	// A trusted post ID saves the search
+	if entry != nil && now.Before(entry.PostExpires) {
+		postAndComments, err := c.api.GetPost(c.ctx, entry.PostID)
+		if err == nil {
+			return c.fill(gen, key, postAndComments)
+		}
+		c.cache.invalidate(key)
+	}
+
+	post, err := c.findPostByTitle(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find key: %w", err)
+	}
+	if post == nil {
+		c.cache.invalidate(key)
+		return nil, &KeyNotFoundError{Key: key}
+	}
+
+	// Conditional refresh: appends change the comment count, and
+	// overwrites change the post
+	if entry != nil && c.cache.current(entry, post, now) {
+		c.cache.renew(key, entry)
+		c.cache.count(&c.cache.stats.Revalidations)
+		return cloneNode(entry.Value), nil
+	}
+
+	postAndComments, err := c.api.GetPost(c.ctx, post.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	return c.fill(gen, key, postAndComments)
+}
+
+// fill converts a fetched post to a value tree and caches it.
+func (c *KVClient) fill(gen uint64, key string, postAndComments *reddit.PostAndComments) (*ValueNode, error) {
+	c.cache.count(&c.cache.stats.Misses)
+
+	value, err := valueFromPost(key, postAndComments)
+	if err != nil {
+		c.cache.invalidate(key)
+		return nil, err
+	}
+	c.cache.store(gen, key, postAndComments.Post, value)
+	return value, nil
+}
+
+// cloneNode deep-copies a value tree so callers can't modify cached data.
+func cloneNode(node *ValueNode) *ValueNode {
+	clone := *node
+	clone.Meta = maps.Clone(node.Meta)
+	if node.Children != nil {
+		clone.Children = make([]ValueNode, len(node.Children))
+		for i := range node.Children {
+			clone.Children[i] = *cloneNode(&node.Children[i])
+		}
+	}
+	return &clone
+}
@@ -0,0 +1,176 @@
+package redditkv
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// counting returns a middleware that counts calls per operation.
+func counting() (Middleware, map[string]int) {
+	calls := make(map[string]int)
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (any, error) {
+			calls[call.Op]++
+			return next(ctx, call)
+		}
+	}, calls
+}
+
+func TestCacheHit(t *testing.T) {
+	mock := NewMockRedditAPI()
+	count, calls := counting()
+	client := NewWithAPI(mock, "testsubreddit", WithMiddleware(count), WithCache(CacheConfig{ValueTTL: time.Hour}))
+
+	_ = client.Set("mykey", "root")
+	if _, err := client.Get("mykey"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	clear(calls)
+
+	value, err := client.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "root" {
+		t.Errorf("Expected 'root', got '%s'", value.Value)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected no API calls, got %v", calls)
+	}
+
+	// Callers get their own copy
+	value.Value = "changed"
+	if value, _ := client.Get("mykey"); value.Value != "root" {
+		t.Errorf("Expected cached value to be unaffected, got '%s'", value.Value)
+	}
+
+	stats := client.CacheStats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Size != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit", WithCache(CacheConfig{ValueTTL: time.Hour}))
+
+	_ = client.Set("mykey", "first")
+	_, _ = client.Get("mykey")
+
+	_ = client.Set("mykey", "second")
+	if value, _ := client.Get("mykey"); value.Value != "second" {
+		t.Errorf("Expected 'second' after Set, got '%s'", value.Value)
+	}
+
+	_ = client.Append("mykey", "child", []int{0})
+	if value, _ := client.Get("mykey"); len(value.Children) != 1 {
+		t.Errorf("Expected the appended child, got %+v", value)
+	}
+
+	_ = client.Delete("mykey")
+	if _, err := client.Get("mykey"); err == nil {
+		t.Error("Expected KeyNotFoundError after Delete")
+	}
+}
+
+func TestCacheConditionalRefresh(t *testing.T) {
+	mock := NewMockRedditAPI()
+	count, calls := counting()
+	client := NewWithAPI(mock, "testsubreddit", WithMiddleware(count), WithCache(CacheConfig{}))
+	other := NewWithAPI(mock, "testsubreddit")
+
+	_ = other.Set("mykey", "root")
+	_, _ = client.Get("mykey")
+	clear(calls)
+
+	// Unchanged comment count: only the search is needed
+	if _, err := client.Get("mykey"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if calls["SearchPosts"] != 1 || calls["GetPost"] != 0 {
+		t.Errorf("Expected a search only, got %v", calls)
+	}
+
+	// Another client's append changes the count, forcing a refetch
+	_ = other.Append("mykey", "child", []int{0})
+	clear(calls)
+	value, err := client.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(value.Children) != 1 {
+		t.Errorf("Expected the other client's append, got %+v", value)
+	}
+	if calls["GetPost"] != 1 {
+		t.Errorf("Expected a refetch, got %v", calls)
+	}
+
+	stats := client.CacheStats()
+	if stats.Revalidations != 1 || stats.Misses != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestCachePostID(t *testing.T) {
+	mock := NewMockRedditAPI()
+	count, calls := counting()
+	client := NewWithAPI(mock, "testsubreddit", WithMiddleware(count), WithCache(CacheConfig{PostTTL: time.Hour}))
+
+	_ = client.Set("mykey", "root")
+	_, _ = client.Get("mykey")
+	clear(calls)
+
+	// The value is stale, but the post ID saves the search
+	if _, err := client.Get("mykey"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if calls["SearchPosts"] != 0 || calls["GetPost"] != 1 {
+		t.Errorf("Expected a post fetch only, got %v", calls)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit", WithCache(CacheConfig{Size: 2, ValueTTL: time.Hour}))
+
+	for _, key := range []string{"a", "b", "c"} {
+		_ = client.Set(key, key)
+		_, _ = client.Get(key)
+	}
+
+	stats := client.CacheStats()
+	if stats.Size != 2 || stats.Evictions != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// "a" was least recently used
+	_, _ = client.Get("a")
+	if stats := client.CacheStats(); stats.Misses != 4 {
+		t.Errorf("Expected 'a' to be refetched, got %+v", stats)
+	}
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit", WithCache(CacheConfig{MaxAge: 50 * time.Millisecond}))
+
+	_ = client.Set("mykey", "first")
+	_, _ = client.Get("mykey")
+
+	// An edit in place leaves the post and its comment count as they were
+	post, _ := client.findPostByTitle("mykey")
+	postAndComments, _ := mock.GetPost(client.ctx, post.ID)
+	_, _ = mock.EditComment(client.ctx, postAndComments.Comments[0].FullID, "second")
+
+	if value, _ := client.Get("mykey"); value.Value != "first" {
+		t.Errorf("Expected the revalidated 'first', got '%s'", value.Value)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	value, err := client.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "second" {
+		t.Errorf("Expected the edit once the entry is too old, got '%s'", value.Value)
+	}
+}
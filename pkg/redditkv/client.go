@@ -2,40 +2,68 @@ package redditkv
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/vartanbeno/go-reddit/v2/reddit"
 )
 
 // KVClient implements the Client interface using Reddit as a backend.
+//
+// A KVClient is safe for concurrent use by multiple goroutines. Concurrent
+// Get or Exists calls for the same key share one set of API calls, and a
+// read started after a write through the same client has finished always
+// sees that write.
 type KVClient struct {
 	api       RedditAPI
 	subreddit string
 	ctx       context.Context
+
+	autoMigrate  bool
+	settleDelay  time.Duration
+	cache        *readCache
+	concurrency  int
+	pollInterval time.Duration
+
+	deadLetterThreshold int
+	compactAfter        int
+
+	flights flightGroup
+	rate    rateGate      // shared by multi-key operations
+	writes  atomic.Uint64 // finished writes, see flightKey
 }
 
 // New creates a new reddit-kv client with the given configuration.
-func New(cfg Config) (*KVClient, error) {
-	api, err := NewRedditAPI(cfg)
+func New(cfg Config, opts ...Option) (*KVClient, error) {
+	api, err := NewAPI(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Reddit API client: %w", err)
 	}
 
-	return &KVClient{
-		api:       api,
-		subreddit: cfg.Subreddit,
-		ctx:       context.Background(),
-	}, nil
+	// Reddit's search index lags behind writes; a local database doesn't
+	if !isLocalBackend(cfg) {
+		opts = append([]Option{WithSettleDelay(defaultSettleDelay)}, opts...)
+	}
+
+	return NewWithAPI(api, cfg.Subreddit, opts...), nil
 }
 
 // NewWithAPI creates a new reddit-kv client with a custom RedditAPI implementation.
 // This is useful for testing with a mock.
-func NewWithAPI(api RedditAPI, subreddit string) *KVClient {
-	return &KVClient{
+func NewWithAPI(api RedditAPI, subreddit string, opts ...Option) *KVClient {
+	c := &KVClient{
 		api:       api,
 		subreddit: subreddit,
 		ctx:       context.Background(),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // Set creates or overwrites a key with a scalar value.
@@ -46,30 +74,76 @@ func (c *KVClient) Set(key, value string) error {
 		return fmt.Errorf("failed to check existing key: %w", err)
 	}
 
+	_, err = c.replace(key, value, nil, existingPost)
+	return err
+}
+
+// SetWithMeta is like Set but also stores metadata alongside the key.
+// The metadata is returned on the root node by Get.
+func (c *KVClient) SetWithMeta(key, value string, meta map[string]string) error {
+	existingPost, err := c.findPostByTitle(key)
+	if err != nil {
+		return fmt.Errorf("failed to check existing key: %w", err)
+	}
+
+	_, err = c.replace(key, value, meta, existingPost)
+	return err
+}
+
+// replace deletes the existing post (if any) and writes a fresh one
+// holding a single value comment and the given metadata.
+func (c *KVClient) replace(key, value string, meta map[string]string, existingPost *reddit.Post) (*reddit.Submitted, error) {
+	defer c.invalidate(key)
+
+	body, err := encodeMeta(meta)
+	if err != nil {
+		return nil, err
+	}
+
 	// Delete existing post if found (overwrite behavior)
 	if existingPost != nil {
 		if err := c.api.DeletePost(c.ctx, existingPost.ID); err != nil {
-			return fmt.Errorf("failed to delete existing key: %w", err)
+			return nil, fmt.Errorf("failed to delete existing key: %w", err)
 		}
 	}
 
-	// Create new post with empty body (title is the key)
-	submitted, err := c.api.SubmitPost(c.ctx, c.subreddit, key, "")
+	// Create new post (title is the key, body holds any metadata)
+	submitted, err := c.api.SubmitPost(c.ctx, c.subreddit, key, body)
 	if err != nil {
-		return fmt.Errorf("failed to create post: %w", err)
+		return nil, fmt.Errorf("failed to create post: %w", err)
 	}
 
 	// Add the value as a comment
 	_, err = c.api.SubmitComment(c.ctx, submitted.FullID, value)
 	if err != nil {
-		return fmt.Errorf("failed to create comment: %w", err)
+		return nil, fmt.Errorf("failed to create comment: %w", err)
 	}
 
-	return nil
+	return submitted, nil
 }
 
 // Get retrieves the value tree for a key.
+// The root node carries the key's current version token.
 func (c *KVClient) Get(key string) (*ValueNode, error) {
+	v, err, shared := c.flights.do(c.flightKey("Get", key), func() (any, error) {
+		return c.get(key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Each caller gets its own copy of a shared tree
+	value := v.(*ValueNode)
+	if shared {
+		value = cloneNode(value)
+	}
+	return value, nil
+}
+
+func (c *KVClient) get(key string) (*ValueNode, error) {
+	if c.cache != nil {
+		return c.cachedGet(key)
+	}
+
 	post, err := c.findPostByTitle(key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find key: %w", err)
@@ -84,56 +158,119 @@ func (c *KVClient) Get(key string) (*ValueNode, error) {
 		return nil, fmt.Errorf("failed to get post: %w", err)
 	}
 
+	return valueFromPost(key, postAndComments)
+}
+
+// valueFromPost converts a fetched post to the key's value tree.
+func valueFromPost(key string, postAndComments *reddit.PostAndComments) (*ValueNode, error) {
 	// Convert comments to ValueNode tree
 	if len(postAndComments.Comments) == 0 {
 		return nil, &KeyNotFoundError{Key: key}
 	}
 
+	// Counters read as their total rather than as a tree of deltas
+	if keyType(postAndComments.Post) == counterType {
+		state := foldCounter(postAndComments.Comments)
+		return &ValueNode{
+			Value:   strconv.FormatInt(state.total, 10),
+			Version: versionOf(postAndComments),
+			Meta:    decodeMeta(postAndComments.Post.Body),
+		}, nil
+	}
+
+	// Moderator-removed or deleted comments would otherwise come back as
+	// "[removed]" and be indistinguishable from a real value
+	if path := findRemovedComment(postAndComments.Comments); path != nil {
+		return nil, &RemovedValueError{Key: key, Path: path}
+	}
+
 	// The root of our value tree is the first top-level comment
 	// If there are multiple top-level comments, we need to handle that
-	return commentsToValueTree(postAndComments.Comments), nil
+	root := commentsToValueTree(postAndComments.Comments)
+	root.Version = versionOf(postAndComments)
+	root.Meta = decodeMeta(postAndComments.Post.Body)
+	return root, nil
 }
 
 // Append adds a value to an existing key's tree.
 func (c *KVClient) Append(key, value string, parentPath []int) error {
+	_, err := c.appendComment(key, value, parentPath)
+	return err
+}
+
+// appendComment implements Append and returns the new comment.
+func (c *KVClient) appendComment(key, value string, parentPath []int) (*reddit.Comment, error) {
+	defer c.invalidate(key)
+
 	post, err := c.findPostByTitle(key)
 	if err != nil {
-		return fmt.Errorf("failed to find key: %w", err)
+		return nil, fmt.Errorf("failed to find key: %w", err)
 	}
 	if post == nil {
-		return &KeyNotFoundError{Key: key}
+		return nil, &KeyNotFoundError{Key: key}
 	}
 
 	// Get post with comments to find the parent
 	postAndComments, err := c.api.GetPost(c.ctx, post.ID)
 	if err != nil {
-		return fmt.Errorf("failed to get post: %w", err)
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+
+	return c.appendToPost(key, postAndComments, value, parentPath)
+}
+
+// appendToPost adds a comment to a key's post that the caller already
+// fetched. The caller invalidates the key.
+func (c *KVClient) appendToPost(key string, postAndComments *reddit.PostAndComments, value string, parentPath []int) (*reddit.Comment, error) {
+	comment, err := c.submitComment(key, postAndComments, value, parentPath)
+
+	// Archived posts reject new comments; move the key to a fresh post
+	var archived *ArchivedKeyError
+	if c.autoMigrate && errors.As(err, &archived) {
+		postAndComments, err = c.migrate(key, postAndComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate archived key: %w", err)
+		}
+		return c.submitComment(key, postAndComments, value, parentPath)
+	}
+	return comment, err
+}
+
+func (c *KVClient) submitComment(key string, postAndComments *reddit.PostAndComments, value string, parentPath []int) (*reddit.Comment, error) {
+	// Locked posts reject new comments
+	if err := checkWritable(key, postAndComments.Post); err != nil {
+		return nil, err
 	}
 
 	var parentID string
 
 	if len(parentPath) == 0 {
 		// Append as new top-level comment (sibling to root)
-		parentID = post.FullID
+		parentID = postAndComments.Post.FullID
 	} else {
 		// Navigate to the parent comment
 		comment, err := navigateToComment(postAndComments.Comments, parentPath)
 		if err != nil {
-			return &InvalidPathError{Path: parentPath}
+			return nil, &InvalidPathError{Path: parentPath}
 		}
 		parentID = comment.FullID
 	}
 
-	_, err = c.api.SubmitComment(c.ctx, parentID, value)
+	comment, err := c.api.SubmitComment(c.ctx, parentID, value)
 	if err != nil {
-		return fmt.Errorf("failed to create comment: %w", err)
+		if stateErr := classifyWriteError(key, err); stateErr != nil {
+			return nil, stateErr
+		}
+		return nil, fmt.Errorf("failed to create comment: %w", err)
 	}
 
-	return nil
+	return comment, nil
 }
 
 // Delete removes a key and all its values.
 func (c *KVClient) Delete(key string) error {
+	defer c.invalidate(key)
+
 	post, err := c.findPostByTitle(key)
 	if err != nil {
 		return fmt.Errorf("failed to find key: %w", err)
@@ -158,9 +295,12 @@ func (c *KVClient) Keys() ([]string, error) {
 		return nil, fmt.Errorf("failed to list posts: %w", err)
 	}
 
-	keys := make([]string, len(posts))
-	for i, post := range posts {
-		keys[i] = post.Title
+	keys := make([]string, 0, len(posts))
+	for _, post := range posts {
+		if isBookkeeping(post.Title) {
+			continue
+		}
+		keys = append(keys, post.Title)
 	}
 
 	return keys, nil
@@ -168,14 +308,56 @@ func (c *KVClient) Keys() ([]string, error) {
 
 // Exists checks if a key exists.
 func (c *KVClient) Exists(key string) (bool, error) {
-	post, err := c.findPostByTitle(key)
+	v, err, _ := c.flights.do(c.flightKey("Exists", key), func() (any, error) {
+		post, err := c.findPostByTitle(key)
+		return post != nil, err
+	})
 	if err != nil {
 		return false, err
 	}
-	return post != nil, nil
+	return v.(bool), nil
+}
+
+// isBookkeeping reports whether a post backs a lock, channel or queue
+// rather than holding user data.
+func isBookkeeping(title string) bool {
+	for _, prefix := range []string{lockKeyPrefix, channelKeyPrefix, queueKeyPrefix} {
+		if strings.HasPrefix(title, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// bookkeepingPost finds or creates the post behind a lock, channel or
+// queue. what names the kind of post in errors.
+func (c *KVClient) bookkeepingPost(key, what string) (*reddit.Post, error) {
+	post, err := c.findPostByTitle(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s: %w", what, err)
+	}
+	if post != nil {
+		return post, nil
+	}
+
+	// Racing creators converge on one post via SetIfAbsent
+	if _, err := c.SetIfAbsent(key, what); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", what, err)
+	}
+
+	post, err = c.findPostByTitle(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s: %w", what, err)
+	}
+	if post == nil {
+		return nil, fmt.Errorf("failed to create %s: %s", what, key)
+	}
+	return post, nil
 }
 
 // findPostByTitle searches for a post with the exact title (key).
+// Search results are sorted newest first, so if a key has duplicate
+// posts the most recently created one wins.
 func (c *KVClient) findPostByTitle(title string) (*reddit.Post, error) {
 	posts, err := c.api.SearchPosts(c.ctx, c.subreddit, title)
 	if err != nil {
@@ -0,0 +1,158 @@
+package redditkv
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// SetIfAbsent sets a key only if it doesn't exist yet (Redis SET NX).
+// It reports whether the value was written.
+//
+// Reddit has no atomic create, so after submitting, SetIfAbsent waits for
+// the search index to settle and re-checks for competing posts. The oldest
+// post wins; if it isn't ours, ours is deleted and false is returned.
+func (c *KVClient) SetIfAbsent(key, value string) (bool, error) {
+	return c.SetIfAbsentWithMeta(key, value, nil)
+}
+
+// SetIfAbsentWithMeta is like SetIfAbsent but also stores metadata
+// alongside the key.
+func (c *KVClient) SetIfAbsentWithMeta(key, value string, meta map[string]string) (bool, error) {
+	existingPost, err := c.findPostByTitle(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to check existing key: %w", err)
+	}
+	if existingPost != nil {
+		return false, nil
+	}
+
+	submitted, err := c.replace(key, value, meta, nil)
+	if err != nil {
+		return false, err
+	}
+
+	return c.resolveRace(key, submitted.ID)
+}
+
+// SetIfPresent overwrites a key only if it already exists (Redis SET XX).
+// It reports whether the value was written. Writers overwriting the key
+// together are settled like SetIfAbsent: the oldest new post wins and the
+// others back out with a ConflictError.
+func (c *KVClient) SetIfPresent(key, value string) (bool, error) {
+	existingPost, err := c.findPostByTitle(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to check existing key: %w", err)
+	}
+	if existingPost == nil {
+		return false, nil
+	}
+
+	submitted, err := c.replace(key, value, nil, existingPost)
+	if err != nil {
+		return false, err
+	}
+	won, err := c.resolveRace(key, submitted.ID)
+	if err != nil {
+		return false, err
+	}
+	if !won {
+		return false, c.lostRace(key)
+	}
+	return true, nil
+}
+
+// GetSet overwrites a key and returns its previous value tree (Redis GETSET).
+// The returned node is nil if the key didn't exist. Concurrent writers
+// are settled as in SetIfPresent; the losers get a ConflictError.
+func (c *KVClient) GetSet(key, value string) (*ValueNode, error) {
+	postAndComments, err := c.currentPost(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var old *ValueNode
+	var existingPost *reddit.Post
+	if postAndComments != nil {
+		existingPost = postAndComments.Post
+		if len(postAndComments.Comments) > 0 {
+			old, err = valueFromPost(key, postAndComments)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	submitted, err := c.replace(key, value, nil, existingPost)
+	if err != nil {
+		return nil, err
+	}
+	won, err := c.resolveRace(key, submitted.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !won {
+		return nil, c.lostRace(key)
+	}
+	return old, nil
+}
+
+// lostRace reports that another writer's post for key won resolveRace.
+func (c *KVClient) lostRace(key string) error {
+	_, actual, _ := c.currentVersion(key)
+	return &ConflictError{Key: key, Actual: actual}
+}
+
+// resolveRace waits for concurrent writers to become visible, then checks
+// whether postID is the oldest post for the key. If another post is older,
+// ours is deleted so the store converges on a single winner.
+func (c *KVClient) resolveRace(key, postID string) (bool, error) {
+	defer c.invalidate(key)
+
+	c.settle()
+
+	posts, err := c.api.SearchPosts(c.ctx, c.subreddit, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to re-check key: %w", err)
+	}
+
+	var oldest *reddit.Post
+	for _, post := range posts {
+		if post.Title != key {
+			continue
+		}
+		if oldest == nil || idLess(post.ID, oldest.ID) {
+			oldest = post
+		}
+	}
+
+	if oldest == nil || oldest.ID == postID {
+		return true, nil
+	}
+
+	if err := c.api.DeletePost(c.ctx, postID); err != nil {
+		return false, fmt.Errorf("failed to back out losing post: %w", err)
+	}
+	return false, nil
+}
+
+// settle sleeps for the configured settle delay, plus up to half of it
+// again as jitter so that racing writers don't re-check in lockstep.
+func (c *KVClient) settle() {
+	if c.settleDelay <= 0 {
+		return
+	}
+	jitter := time.Duration(rand.Int64N(int64(c.settleDelay)/2 + 1))
+	time.Sleep(c.settleDelay + jitter)
+}
+
+// idLess orders Reddit IDs by creation. IDs are base36 counters, so a
+// shorter ID is older and equal-length IDs compare lexically.
+func idLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
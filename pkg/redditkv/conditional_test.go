@@ -0,0 +1,181 @@
+package redditkv
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetIfAbsent(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	ok, err := client.SetIfAbsent("mykey", "first")
+	if err != nil {
+		t.Fatalf("SetIfAbsent failed: %v", err)
+	}
+	if !ok {
+		t.Error("Expected SetIfAbsent to write a new key")
+	}
+
+	ok, err = client.SetIfAbsent("mykey", "second")
+	if err != nil {
+		t.Fatalf("SetIfAbsent failed: %v", err)
+	}
+	if ok {
+		t.Error("Expected SetIfAbsent to leave an existing key alone")
+	}
+
+	value, _ := client.Get("mykey")
+	if value.Value != "first" {
+		t.Errorf("Expected value 'first', got '%s'", value.Value)
+	}
+}
+
+func TestSetIfAbsentConcurrent(t *testing.T) {
+	mock := NewMockRedditAPI()
+
+	const writers = 5
+	results := make([]bool, writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client := NewWithAPI(mock, "testsubreddit", WithSettleDelay(20*time.Millisecond))
+			ok, err := client.SetIfAbsent("init", "done")
+			if err != nil {
+				t.Errorf("SetIfAbsent failed: %v", err)
+			}
+			results[i] = ok
+		}(i)
+	}
+	wg.Wait()
+
+	winners := 0
+	for _, ok := range results {
+		if ok {
+			winners++
+		}
+	}
+	if winners != 1 {
+		t.Errorf("Expected exactly 1 winner, got %d", winners)
+	}
+	if mock.GetPostCount() != 1 {
+		t.Errorf("Expected 1 post, got %d", mock.GetPostCount())
+	}
+}
+
+func TestSetIfPresent(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	ok, err := client.SetIfPresent("mykey", "value")
+	if err != nil {
+		t.Fatalf("SetIfPresent failed: %v", err)
+	}
+	if ok {
+		t.Error("Expected SetIfPresent to skip a missing key")
+	}
+	if mock.GetPostCount() != 0 {
+		t.Errorf("Expected no posts, got %d", mock.GetPostCount())
+	}
+
+	_ = client.Set("mykey", "old")
+	ok, _ = client.SetIfPresent("mykey", "new")
+	if !ok {
+		t.Error("Expected SetIfPresent to overwrite an existing key")
+	}
+
+	value, _ := client.Get("mykey")
+	if value.Value != "new" {
+		t.Errorf("Expected value 'new', got '%s'", value.Value)
+	}
+}
+
+func TestGetSet(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	old, err := client.GetSet("mykey", "v1")
+	if err != nil {
+		t.Fatalf("GetSet failed: %v", err)
+	}
+	if old != nil {
+		t.Errorf("Expected no previous value, got %+v", old)
+	}
+
+	old, err = client.GetSet("mykey", "v2")
+	if err != nil {
+		t.Fatalf("GetSet failed: %v", err)
+	}
+	if old == nil || old.Value != "v1" {
+		t.Errorf("Expected previous value 'v1', got %+v", old)
+	}
+
+	value, _ := client.Get("mykey")
+	if value.Value != "v2" {
+		t.Errorf("Expected value 'v2', got '%s'", value.Value)
+	}
+}
+
+func TestOverwritesConcurrent(t *testing.T) {
+	ops := map[string]func(c *KVClient, value string) error{
+		"SetIfPresent": func(c *KVClient, value string) error {
+			_, err := c.SetIfPresent("mykey", value)
+			return err
+		},
+		"GetSet": func(c *KVClient, value string) error {
+			_, err := c.GetSet("mykey", value)
+			return err
+		},
+	}
+	for name, op := range ops {
+		t.Run(name, func(t *testing.T) {
+			client, fake := newFakeClient(t)
+			_ = client.Set("mykey", "v0")
+
+			const writers = 5
+			errs := make([]error, writers)
+			var wg sync.WaitGroup
+			for i := range writers {
+				writer, err := New(fake.Config("kvtest"), WithSettleDelay(20*time.Millisecond))
+				if err != nil {
+					t.Fatalf("New failed: %v", err)
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs[i] = op(writer, fmt.Sprintf("v%d", i+1))
+				}()
+			}
+			wg.Wait()
+
+			// Writers that got in one after another all succeed; of those
+			// that overlapped, only one may
+			winners := make(map[string]bool)
+			for i, err := range errs {
+				var conflict *ConflictError
+				switch {
+				case err == nil:
+					winners[fmt.Sprintf("v%d", i+1)] = true
+				case !errors.As(err, &conflict):
+					t.Errorf("Expected ConflictError, got %v", err)
+				}
+			}
+
+			value, err := client.Get("mykey")
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			if !winners[value.Value] {
+				t.Errorf("Expected a value some writer was told it wrote, got %s (winners %v)", value.Value, winners)
+			}
+			if n := fake.Store.GetPostCount(); n != 1 {
+				t.Errorf("Expected 1 post, got %d", n)
+			}
+		})
+	}
+}
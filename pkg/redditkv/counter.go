@@ -0,0 +1,178 @@
+package redditkv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// counterType is the data type of counter keys.
+const counterType = "counter"
+
+// defaultCompactAfter is how many deltas a counter collects before Incr
+// compacts it, unless WithCounterCompaction says otherwise. Reddit only
+// lists a few hundred top-level comments per post, so deltas can't be
+// left to pile up.
+const defaultCompactAfter = 50
+
+// WithCounterCompaction sets how many deltas a counter collects before
+// Incr compacts it into a single snapshot.
+func WithCounterCompaction(n int) Option {
+	return func(c *KVClient) {
+		c.compactAfter = n
+	}
+}
+
+// Counters are keys whose top-level comments are deltas, like "+5" or
+// "-2", and snapshots, like "=40@k3x9q2": the total of every delta up to
+// and including comment k3x9q2. A counter's value is the best snapshot,
+// the one covering the newest delta, plus every delta after it.
+// Incrementing only ever appends, so concurrent increments aren't lost.
+//
+// Compaction posts a new snapshot, then deletes the deltas and snapshots
+// it covers. Until they're gone the new snapshot already outranks them,
+// so readers never count a delta twice.
+
+// counterState is a counter's comments folded into its total.
+type counterState struct {
+	total   int64
+	covered string // newest delta in the total; empty if none
+	pending int    // deltas after the best snapshot
+
+	// comments holds every delta and snapshot; compaction replaces them
+	comments []*reddit.Comment
+}
+
+// Incr adds delta to a counter and returns its new total, as of this
+// increment; concurrent increments may have landed as well. The key is
+// created if it doesn't exist yet. Incr fails with a WrongTypeError on
+// keys that aren't counters.
+func (c *KVClient) Incr(key string, delta int64) (int64, error) {
+	defer c.invalidate(key)
+
+	body := fmt.Sprintf("%+d", delta)
+	pc, created, err := c.typedPost(key, counterType, body)
+	if err != nil {
+		return 0, err
+	}
+	if created {
+		return delta, nil
+	}
+
+	state := foldCounter(pc.Comments)
+	if _, err := c.appendToPost(key, pc, body, nil); err != nil {
+		return 0, err
+	}
+
+	threshold := c.compactAfter
+	if threshold <= 0 {
+		threshold = defaultCompactAfter
+	}
+	if state.pending+1 >= threshold {
+		// The increment already landed; a failed compaction is retried
+		// by the next one
+		_, _ = c.compactCounter(key, pc.Post.ID)
+	}
+	return state.total + delta, nil
+}
+
+// CompactCounter rewrites a counter's deltas into a single snapshot and
+// returns its total. Incr does this on its own every so often.
+func (c *KVClient) CompactCounter(key string) (int64, error) {
+	defer c.invalidate(key)
+
+	post, err := c.findOldestPost(key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find key: %w", err)
+	}
+	if post == nil {
+		return 0, &KeyNotFoundError{Key: key}
+	}
+	if keyType(post) != counterType {
+		return 0, &WrongTypeError{Key: key, Type: counterType}
+	}
+	return c.compactCounter(key, post.ID)
+}
+
+func (c *KVClient) compactCounter(key, postID string) (int64, error) {
+	pc, err := c.api.GetPost(c.ctx, postID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get post: %w", err)
+	}
+
+	state := foldCounter(pc.Comments)
+	if len(state.comments) <= 1 {
+		return state.total, nil
+	}
+
+	snapshot := fmt.Sprintf("=%d@%s", state.total, state.covered)
+	if _, err := c.appendToPost(key, pc, snapshot, nil); err != nil {
+		return 0, fmt.Errorf("failed to compact counter: %w", err)
+	}
+
+	for _, comment := range state.comments {
+		if err := c.api.DeleteComment(c.ctx, comment.FullID); err != nil {
+			return state.total, fmt.Errorf("failed to compact counter: %w", err)
+		}
+	}
+	return state.total, nil
+}
+
+// foldCounter adds up a counter's comments. Comments that are neither
+// deltas nor snapshots are ignored.
+func foldCounter(comments []*reddit.Comment) counterState {
+	type snapshot struct {
+		total   int64
+		covered string
+		id      string
+	}
+
+	var (
+		best   *snapshot
+		deltas []*reddit.Comment
+		state  counterState
+	)
+	for _, comment := range comments {
+		body := comment.Body
+		switch {
+		case strings.HasPrefix(body, "="):
+			totalPart, covered, _ := strings.Cut(body[1:], "@")
+			total, err := strconv.ParseInt(totalPart, 10, 64)
+			if err != nil {
+				continue
+			}
+			s := &snapshot{total: total, covered: covered, id: comment.ID}
+			if best == nil || idLess(best.covered, s.covered) ||
+				(best.covered == s.covered && idLess(best.id, s.id)) {
+				best = s
+			}
+		case strings.HasPrefix(body, "+"), strings.HasPrefix(body, "-"):
+			if _, err := strconv.ParseInt(body, 10, 64); err != nil {
+				continue
+			}
+			deltas = append(deltas, comment)
+		default:
+			continue
+		}
+		state.comments = append(state.comments, comment)
+	}
+
+	if best != nil {
+		state.total = best.total
+		state.covered = best.covered
+	}
+	for _, comment := range deltas {
+		if best != nil && best.covered != "" && !idLess(best.covered, comment.ID) {
+			continue
+		}
+		delta, _ := strconv.ParseInt(comment.Body, 10, 64)
+		state.total += delta
+		state.pending++
+		if state.covered == "" || idLess(state.covered, comment.ID) {
+			state.covered = comment.ID
+		}
+	}
+	return state
+}
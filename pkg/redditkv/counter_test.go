@@ -0,0 +1,97 @@
+package redditkv
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIncr(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit")
+
+	total, err := client.Incr("hits", 5)
+	if err != nil {
+		t.Fatalf("Incr failed: %v", err)
+	}
+	if total != 5 {
+		t.Errorf("Expected 5, got %d", total)
+	}
+
+	total, err = client.Incr("hits", -2)
+	if err != nil {
+		t.Fatalf("Incr failed: %v", err)
+	}
+	if total != 3 {
+		t.Errorf("Expected 3, got %d", total)
+	}
+
+	value, err := client.Get("hits")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "3" || len(value.Children) != 0 {
+		t.Errorf("Expected Get to fold the deltas into 3, got %+v", value)
+	}
+
+	_ = client.Set("plain", "value")
+	var wrongType *WrongTypeError
+	if _, err := client.Incr("plain", 1); !errors.As(err, &wrongType) {
+		t.Errorf("Expected WrongTypeError, got %v", err)
+	}
+}
+
+func TestCompactCounter(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit", WithCounterCompaction(100))
+	for i := 0; i < 10; i++ {
+		_, _ = client.Incr("hits", 1)
+	}
+
+	total, err := client.CompactCounter("hits")
+	if err != nil {
+		t.Fatalf("CompactCounter failed: %v", err)
+	}
+	if total != 10 {
+		t.Errorf("Expected 10, got %d", total)
+	}
+	if mock.GetCommentCount() != 1 {
+		t.Errorf("Expected a single snapshot, got %d comments", mock.GetCommentCount())
+	}
+
+	total, _ = client.Incr("hits", 1)
+	value, _ := client.Get("hits")
+	if total != 11 || value.Value != "11" {
+		t.Errorf("Expected 11 after the snapshot, got %d and %s", total, value.Value)
+	}
+}
+
+func TestIncrConcurrent(t *testing.T) {
+	mock := NewMockRedditAPI()
+	mock.SetLatency(time.Millisecond)
+
+	// A low threshold makes compactions overlap with increments
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		client := NewWithAPI(mock, "testsubreddit", WithCounterCompaction(5))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				if _, err := client.Incr("hits", 1); err != nil {
+					t.Errorf("Incr failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	value, err := NewWithAPI(mock, "testsubreddit").Get("hits")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "80" {
+		t.Errorf("Expected 80, got %s", value.Value)
+	}
+}
@@ -0,0 +1,205 @@
+package redditkv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheDir returns the directory holding on-disk caches.
+func CacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
+	}
+
+	return filepath.Join(cacheDir, configDirName), nil
+}
+
+// CachePath returns the on-disk cache file for a configuration. Each
+// backend and subreddit gets its own file, so their keys don't mix.
+func CachePath(cfg Config) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, configHash(cfg)+".json"), nil
+}
+
+// configHash identifies the store a configuration points at.
+func configHash(cfg Config) string {
+	sum := sha256.Sum256([]byte(cfg.Backend + "\n" + cfg.BaseURL + "\n" + cfg.Subreddit))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ClearCache deletes all on-disk caches.
+func ClearCache() error {
+	dir, err := CacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	return nil
+}
+
+// WithDiskCache is like WithCache, but keeps the cache in a file so it
+// survives between processes, such as successive CLI invocations. Several
+// processes can share one file; every access holds an advisory lock.
+// Cache file errors are ignored, falling back to the API.
+func WithDiskCache(path string, cfg CacheConfig) Option {
+	return func(c *KVClient) {
+		cfg = cfg.withDefaults()
+		c.cache = &readCache{cfg: cfg, entries: &diskStore{path: path, size: cfg.Size}}
+	}
+}
+
+// diskStore is a cacheStore kept in a JSON file. Each operation reloads
+// the file under a lock and writes it back if an entry changed. Hits
+// don't count as changes, so eviction goes by when entries were last
+// stored or renewed rather than by when they were last read.
+type diskStore struct {
+	path string
+	size int
+	mu   sync.Mutex
+}
+
+// diskCache is the on-disk format.
+type diskCache struct {
+	Gen     uint64                `json:"gen"`
+	Entries map[string]*diskEntry `json:"entries"`
+}
+
+type diskEntry struct {
+	cacheEntry
+	LastUsed time.Time `json:"last_used"`
+}
+
+// with loads the cache file, runs fn, and saves the result if fn
+// reports a change.
+func (ds *diskStore) with(fn func(dc *diskCache) bool) error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(ds.path), 0700); err != nil {
+		return err
+	}
+	unlock, err := lockFile(ds.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	dc := &diskCache{}
+	data, err := os.ReadFile(ds.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// A corrupt cache file is simply started over
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, dc)
+	}
+	if dc.Entries == nil {
+		dc.Entries = make(map[string]*diskEntry)
+	}
+
+	if !fn(dc) {
+		return nil
+	}
+
+	data, err = json.Marshal(dc)
+	if err != nil {
+		return err
+	}
+	tmp := ds.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ds.path)
+}
+
+func (ds *diskStore) lookup(key string) (*cacheEntry, uint64) {
+	var entry *cacheEntry
+	var gen uint64
+	err := ds.with(func(dc *diskCache) bool {
+		gen = dc.Gen
+		if e, ok := dc.Entries[key]; ok {
+			copied := e.cacheEntry
+			entry = &copied
+		}
+		return false
+	})
+	if err != nil {
+		// The generation can't match on store, so nothing is cached
+		return nil, ^uint64(0)
+	}
+	return entry, gen
+}
+
+func (ds *diskStore) store(gen uint64, key string, entry *cacheEntry) int {
+	evicted := 0
+	_ = ds.with(func(dc *diskCache) bool {
+		if gen != dc.Gen {
+			return false
+		}
+		dc.Entries[key] = &diskEntry{cacheEntry: *entry, LastUsed: time.Now()}
+
+		for len(dc.Entries) > ds.size {
+			var oldest string
+			for k, e := range dc.Entries {
+				if oldest == "" || e.LastUsed.Before(dc.Entries[oldest].LastUsed) {
+					oldest = k
+				}
+			}
+			delete(dc.Entries, oldest)
+			evicted++
+		}
+		return true
+	})
+	return evicted
+}
+
+func (ds *diskStore) renew(key string, valueExpires, postExpires time.Time) {
+	_ = ds.with(func(dc *diskCache) bool {
+		e, ok := dc.Entries[key]
+		if !ok {
+			return false
+		}
+		e.ValueExpires = valueExpires
+		e.PostExpires = postExpires
+		e.LastUsed = time.Now()
+		return true
+	})
+}
+
+func (ds *diskStore) invalidate(key string) {
+	_ = ds.with(func(dc *diskCache) bool {
+		dc.Gen++
+		delete(dc.Entries, key)
+		return true
+	})
+}
+
+func (ds *diskStore) purge() {
+	_ = ds.with(func(dc *diskCache) bool {
+		dc.Gen++
+		clear(dc.Entries)
+		return true
+	})
+}
+
+func (ds *diskStore) len() int {
+	n := 0
+	_ = ds.with(func(dc *diskCache) bool {
+		n = len(dc.Entries)
+		return false
+	})
+	return n
+}
@@ -0,0 +1,116 @@
+package redditkv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDiskCacheShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	cfg := CacheConfig{ValueTTL: time.Hour, PostTTL: time.Hour}
+	mock := NewMockRedditAPI()
+
+	// Two clients stand in for two CLI invocations
+	first := NewWithAPI(mock, "testsubreddit", WithDiskCache(path, cfg))
+	_ = first.Set("mykey", "root")
+	if _, err := first.Get("mykey"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	count, calls := counting()
+	second := NewWithAPI(mock, "testsubreddit", WithMiddleware(count), WithDiskCache(path, cfg))
+	value, err := second.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "root" {
+		t.Errorf("Expected 'root', got '%s'", value.Value)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected the value from disk without API calls, got %v", calls)
+	}
+
+	// A write in one process invalidates the other's view
+	_ = second.Set("mykey", "changed")
+	if value, _ := first.Get("mykey"); value.Value != "changed" {
+		t.Errorf("Expected 'changed', got '%s'", value.Value)
+	}
+}
+
+func TestDiskCacheHitsDontWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit",
+		WithDiskCache(path, CacheConfig{ValueTTL: time.Hour, PostTTL: time.Hour}))
+	_ = client.Set("mykey", "root")
+	_, _ = client.Get("mykey")
+
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	_, _ = client.Get("mykey")
+	after, _ := os.ReadFile(path)
+	if string(after) != string(before) {
+		t.Error("Expected a cache hit to leave the file alone")
+	}
+}
+
+func TestDiskCacheConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	mock := NewMockRedditAPI()
+	for i := range 5 {
+		_ = NewWithAPI(mock, "testsubreddit").Set(fmt.Sprintf("key%d", i), "value")
+	}
+
+	var wg sync.WaitGroup
+	for i := range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client := NewWithAPI(mock, "testsubreddit", WithDiskCache(path, CacheConfig{ValueTTL: time.Hour}))
+			for range 5 {
+				if _, err := client.Get(fmt.Sprintf("key%d", i)); err != nil {
+					t.Errorf("Get failed: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	client := NewWithAPI(mock, "testsubreddit", WithDiskCache(path, CacheConfig{}))
+	if n := client.CacheStats().Size; n != 5 {
+		t.Errorf("Expected all 5 keys in the shared cache, got %d", n)
+	}
+}
+
+func TestClearCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	path, err := CachePath(Config{Subreddit: "testsubreddit"})
+	if err != nil {
+		t.Fatalf("CachePath failed: %v", err)
+	}
+	other, _ := CachePath(Config{Subreddit: "testsubreddit", Backend: "local:/tmp/kv.json"})
+	if path == other {
+		t.Error("Expected different cache files for different backends")
+	}
+
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit", WithDiskCache(path, CacheConfig{ValueTTL: time.Hour}))
+	_ = client.Set("mykey", "root")
+	_, _ = client.Get("mykey")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Expected cache file: %v", err)
+	}
+
+	if err := ClearCache(); err != nil {
+		t.Fatalf("ClearCache failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected cache file to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,391 @@
+package redditkv
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// Credentials accepted by FakeReddit.
+const (
+	FakeClientID     = "fake-client-id"
+	FakeClientSecret = "fake-client-secret"
+	FakeUsername     = "fake-user"
+	FakePassword     = "fake-password"
+)
+
+// FakeReddit is an in-process imitation of Reddit's OAuth and REST
+// endpoints, backed by a MockRedditAPI. Point a client at it with
+// Config to exercise the real go-reddit wiring offline:
+//
+//	POST /api/v1/access_token    password grant, client ID/secret in basic auth
+//	POST /api/submit             submit a self post
+//	POST /api/comment            reply to a post or comment
+//	POST /api/editusertext       edit a comment
+//	POST /api/del                delete a post or comment by full ID
+//	GET  /comments/{id}          a post and its comment tree
+//	POST /api/morechildren       comments left out of a tree
+//	GET  /r/{sub}/new            newest posts
+//	GET  /r/{sub}/search         search posts by title
+//
+// Like Reddit, write failures are reported as JSON errors with a 200
+// status, and /api/del silently ignores IDs it doesn't recognize.
+type FakeReddit struct {
+	*httptest.Server
+
+	// Store holds the fake's posts and comments.
+	Store *MockRedditAPI
+
+	mu            sync.Mutex
+	tokens        map[string]bool
+	tokenRequests int
+	failures      map[string]string // path -> error label for the next request
+	inline        int               // comments per tree, see SetInlineComments
+}
+
+// NewFakeReddit starts a fake Reddit server. Call Close when done.
+func NewFakeReddit() *FakeReddit {
+	f := &FakeReddit{
+		Store:    NewMockRedditAPI(),
+		tokens:   make(map[string]bool),
+		failures: make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/access_token", f.handleToken)
+	mux.HandleFunc("POST /api/submit", f.authed(f.handleSubmit))
+	mux.HandleFunc("POST /api/comment", f.authed(f.handleComment))
+	mux.HandleFunc("POST /api/editusertext", f.authed(f.handleEdit))
+	mux.HandleFunc("POST /api/del", f.authed(f.handleDelete))
+	mux.HandleFunc("GET /comments/{id}", f.authed(f.handleComments))
+	mux.HandleFunc("POST /api/morechildren", f.authed(f.handleMoreChildren))
+	mux.HandleFunc("GET /by_id/{names}", f.authed(f.handleByID))
+	mux.HandleFunc("GET /r/{sub}/new", f.authed(f.handleNew))
+	mux.HandleFunc("GET /r/{sub}/search", f.authed(f.handleSearch))
+
+	f.Server = httptest.NewServer(mux)
+	return f
+}
+
+// Config returns a client configuration that talks to the fake.
+func (f *FakeReddit) Config(subreddit string) Config {
+	return Config{
+		ClientID:     FakeClientID,
+		ClientSecret: FakeClientSecret,
+		Username:     FakeUsername,
+		Password:     FakePassword,
+		Subreddit:    subreddit,
+		BaseURL:      f.URL + "/",
+		TokenURL:     f.URL + "/api/v1/access_token",
+	}
+}
+
+// TokenRequests returns how many access tokens have been issued.
+func (f *FakeReddit) TokenRequests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tokenRequests
+}
+
+// FailNext makes the next request to path (e.g. "/api/comment") fail
+// with a Reddit JSON error carrying label, such as "RATELIMIT".
+func (f *FakeReddit) FailNext(path, label string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[path] = label
+}
+
+// SetInlineComments makes /comments/{id} return at most n comments, as
+// Reddit does with big trees, leaving the rest behind "more" stubs that
+// /api/morechildren resolves. Zero returns every comment.
+func (f *FakeReddit) SetInlineComments(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inline = n
+}
+
+func (f *FakeReddit) handleToken(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != FakeClientID || secret != FakeClientSecret {
+		http.Error(w, `{"error": "invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+	if r.FormValue("grant_type") != "password" ||
+		r.FormValue("username") != FakeUsername || r.FormValue("password") != FakePassword {
+		writeFakeJSON(w, http.StatusOK, map[string]any{"error": "invalid_grant"})
+		return
+	}
+
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	token := hex.EncodeToString(buf)
+
+	f.mu.Lock()
+	f.tokens[token] = true
+	f.tokenRequests++
+	f.mu.Unlock()
+
+	writeFakeJSON(w, http.StatusOK, map[string]any{
+		"access_token": token,
+		"token_type":   "bearer",
+		"expires_in":   3600,
+		"scope":        "*",
+	})
+}
+
+// authed rejects requests without a valid bearer token, applies any
+// injected failure, and serializes access to the store so responses
+// are consistent snapshots.
+func (f *FakeReddit) authed(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !f.tokens[token] {
+			writeFakeJSON(w, http.StatusUnauthorized, map[string]any{"message": "Unauthorized", "error": 401})
+			return
+		}
+
+		if label, ok := f.failures[r.URL.Path]; ok {
+			delete(f.failures, r.URL.Path)
+			writeAPIError(w, label, "injected failure", "")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (f *FakeReddit) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	sub, title := r.FormValue("sr"), r.FormValue("title")
+	if sub == "" || title == "" {
+		writeAPIError(w, "NO_TEXT", "we need something here", "title")
+		return
+	}
+
+	submitted, err := f.Store.SubmitPost(r.Context(), sub, title, r.FormValue("text"))
+	if err != nil {
+		writeFakeJSON(w, http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return
+	}
+	f.Store.mu.Lock()
+	f.Store.posts[submitted.ID].post.Author = FakeUsername
+	f.Store.mu.Unlock()
+
+	writeFakeJSON(w, http.StatusOK, map[string]any{
+		"json": map[string]any{
+			"errors": []any{},
+			"data":   submitted,
+		},
+	})
+}
+
+func (f *FakeReddit) handleComment(w http.ResponseWriter, r *http.Request) {
+	parent := r.FormValue("parent")
+	post := f.postOf(parent)
+	if post == nil {
+		writeAPIError(w, "INVALID_THING_ID", "that thing doesn't exist", "parent")
+		return
+	}
+	if f.Store.isArchived(parent) {
+		writeAPIError(w, "TOO_OLD", "that's a piece of history now", "parent")
+		return
+	}
+	if post.Locked {
+		writeAPIError(w, "THREAD_LOCKED", "comments are locked", "parent")
+		return
+	}
+
+	comment, err := f.Store.SubmitComment(r.Context(), parent, r.FormValue("text"))
+	if err != nil {
+		writeFakeJSON(w, http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return
+	}
+	f.Store.mu.Lock()
+	comment.Author = FakeUsername
+	f.Store.mu.Unlock()
+	writeFakeJSON(w, http.StatusOK, wireComment(comment))
+}
+
+func (f *FakeReddit) handleEdit(w http.ResponseWriter, r *http.Request) {
+	id := r.FormValue("thing_id")
+	if f.Store.isArchived(id) {
+		writeAPIError(w, "TOO_OLD", "that's a piece of history now", "thing_id")
+		return
+	}
+
+	if !strings.HasPrefix(id, "t1_") {
+		writeAPIError(w, "INVALID_THING_ID", "that thing doesn't exist", "thing_id")
+		return
+	}
+
+	comment, err := f.Store.EditComment(r.Context(), id, r.FormValue("text"))
+	if err != nil {
+		writeAPIError(w, "INVALID_THING_ID", "that thing doesn't exist", "thing_id")
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, wireComment(comment))
+}
+
+func (f *FakeReddit) handleDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.FormValue("id")
+	switch {
+	case strings.HasPrefix(id, "t3_"):
+		_ = f.Store.DeletePost(r.Context(), id[3:])
+	case strings.HasPrefix(id, "t1_"):
+		_ = f.Store.DeleteComment(r.Context(), id)
+	}
+	writeFakeJSON(w, http.StatusOK, map[string]any{})
+}
+
+func (f *FakeReddit) handleComments(w http.ResponseWriter, r *http.Request) {
+	pc, err := f.Store.GetPost(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeFakeJSON(w, http.StatusNotFound, map[string]any{"message": "Not Found", "error": 404})
+		return
+	}
+
+	if f.inline > 0 {
+		pc = truncateComments(pc, f.inline)
+	}
+	writeFakeJSON(w, http.StatusOK, wirePostAndComments(pc))
+}
+
+func (f *FakeReddit) handleMoreChildren(w http.ResponseWriter, r *http.Request) {
+	things := []any{}
+	f.Store.mu.RLock()
+	for _, id := range strings.Split(r.FormValue("children"), ",") {
+		comment, ok := f.Store.comments[id]
+		if !ok {
+			continue
+		}
+		// Reddit sends the comments flat; each names its parent
+		flat := *comment
+		flat.Replies = reddit.Replies{}
+		things = append(things, wireThing("t1", wireComment(&flat)))
+	}
+	f.Store.mu.RUnlock()
+
+	writeFakeJSON(w, http.StatusOK, map[string]any{
+		"json": map[string]any{
+			"errors": []any{},
+			"data":   map[string]any{"things": things},
+		},
+	})
+}
+
+// truncateComments returns a copy of pc with only its first n comments,
+// in the order Reddit sends them. The others hang off a "more" stub on
+// their nearest ancestor that was kept, or on the post.
+func truncateComments(pc *reddit.PostAndComments, n int) *reddit.PostAndComments {
+	kept := 0
+	var trim func(parentID string, comments []*reddit.Comment) ([]*reddit.Comment, *reddit.More)
+	trim = func(parentID string, comments []*reddit.Comment) ([]*reddit.Comment, *reddit.More) {
+		var shown []*reddit.Comment
+		var more *reddit.More
+		for _, comment := range comments {
+			if kept >= n {
+				if more == nil {
+					more = &reddit.More{ID: comment.ID, FullID: "t1_" + comment.ID, ParentID: parentID}
+				}
+				more.Children = appendSubtree(more.Children, comment)
+				more.Count = len(more.Children)
+				continue
+			}
+			kept++
+			c := *comment
+			c.Replies.Comments, c.Replies.More = trim(c.FullID, comment.Replies.Comments)
+			shown = append(shown, &c)
+		}
+		return shown, more
+	}
+
+	out := &reddit.PostAndComments{Post: pc.Post}
+	out.Comments, out.More = trim(pc.Post.FullID, pc.Comments)
+	return out
+}
+
+// appendSubtree appends the IDs of comment and its replies, parents first.
+func appendSubtree(ids []string, comment *reddit.Comment) []string {
+	ids = append(ids, comment.ID)
+	for _, reply := range comment.Replies.Comments {
+		ids = appendSubtree(ids, reply)
+	}
+	return ids
+}
+
+func (f *FakeReddit) handleByID(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	for _, name := range strings.Split(r.PathValue("names"), ",") {
+		ids = append(ids, strings.TrimPrefix(name, "t3_"))
+	}
+	posts, err := f.Store.GetPosts(r.Context(), ids)
+	if err != nil {
+		writeFakeJSON(w, http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, wirePostListing(posts))
+}
+
+func (f *FakeReddit) handleNew(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.FormValue("limit"))
+	posts, err := f.Store.ListNewPosts(r.Context(), r.PathValue("sub"), &reddit.ListOptions{
+		Limit: limit,
+		After: r.FormValue("after"),
+	})
+	if err != nil {
+		writeFakeJSON(w, http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, wirePostListing(posts))
+}
+
+func (f *FakeReddit) handleSearch(w http.ResponseWriter, r *http.Request) {
+	posts, err := f.Store.SearchPosts(r.Context(), r.PathValue("sub"), r.FormValue("q"))
+	if err != nil {
+		writeFakeJSON(w, http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, wirePostListing(posts))
+}
+
+// postOf returns the post a thing belongs to, or nil if it doesn't exist.
+func (f *FakeReddit) postOf(fullID string) *reddit.Post {
+	f.Store.mu.RLock()
+	defer f.Store.mu.RUnlock()
+
+	if strings.HasPrefix(fullID, "t1_") {
+		comment, ok := f.Store.comments[fullID[3:]]
+		if !ok {
+			return nil
+		}
+		fullID = comment.PostID
+	}
+	if mp, ok := f.Store.posts[strings.TrimPrefix(fullID, "t3_")]; ok {
+		return mp.post
+	}
+	return nil
+}
+
+// writeAPIError writes a Reddit JSON error, which comes with a 200 status.
+func writeAPIError(w http.ResponseWriter, label, reason, field string) {
+	writeFakeJSON(w, http.StatusOK, map[string]any{
+		"json": map[string]any{
+			"errors": [][]string{{label, reason, field}},
+		},
+	})
+}
+
+func writeFakeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,78 @@
+package redditkv
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// flightGroup coalesces concurrent calls with the same key, so only one
+// of them does the work and the rest wait for its result. The zero value
+// is ready to use.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a call in progress or just finished.
+type flight struct {
+	done  chan struct{}
+	value any
+	err   error
+	dups  int
+}
+
+// do runs fn, unless a call with the same key is already running, in
+// which case it waits for that call and returns its result. shared
+// reports whether the result was handed to more than one caller.
+func (g *flightGroup) do(key string, fn func() (any, error)) (value any, err error, shared bool) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	if f, ok := g.flights[key]; ok {
+		f.dups++
+		g.mu.Unlock()
+		<-f.done
+		return f.value, f.err, true
+	}
+	f := &flight{done: make(chan struct{})}
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	returned := false
+	defer func() {
+		// fn panicked or called runtime.Goexit: fail the waiters instead
+		// of leaving them blocked, and let the panic carry on
+		if !returned {
+			f.err = errFlightAborted
+			g.finish(key, f)
+		}
+	}()
+
+	f.value, f.err = fn()
+	returned = true
+
+	return f.value, f.err, g.finish(key, f)
+}
+
+// errFlightAborted is returned to callers waiting on a call that panicked.
+var errFlightAborted = errors.New("coalesced call panicked")
+
+// finish removes a completed flight, wakes its waiters, and reports
+// whether there were any.
+func (g *flightGroup) finish(key string, f *flight) bool {
+	g.mu.Lock()
+	delete(g.flights, key)
+	shared := f.dups > 0
+	g.mu.Unlock()
+	close(f.done)
+	return shared
+}
+
+// flightKey names a coalescable read of key. It includes the number of
+// writes this client has finished, so a read that starts after a write
+// never joins one that started before it and might miss the write.
+func (c *KVClient) flightKey(op, key string) string {
+	return fmt.Sprintf("%s\x00%d\x00%s", op, c.writes.Load(), key)
+}
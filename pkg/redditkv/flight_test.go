@@ -0,0 +1,191 @@
+package redditkv
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gated returns a middleware that holds searches until release is closed.
+func gated(release <-chan struct{}) Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (any, error) {
+			if call.Op == "SearchPosts" {
+				<-release
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+// waitForWaiters waits until n callers are waiting on an in-flight call.
+func waitForWaiters(t *testing.T, c *KVClient, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.flights.mu.Lock()
+		waiting := 0
+		for _, f := range c.flights.flights {
+			waiting += f.dups
+		}
+		c.flights.mu.Unlock()
+		if waiting == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Expected %d waiting callers", n)
+}
+
+func TestConcurrentGetCoalesced(t *testing.T) {
+	mock := NewMockRedditAPI()
+	_ = NewWithAPI(mock, "testsubreddit").Set("mykey", "root")
+	searches, fetches := mock.CallCount("SearchPosts"), mock.CallCount("GetPost")
+
+	release := make(chan struct{})
+	client := NewWithAPI(mock, "testsubreddit", WithMiddleware(gated(release)))
+
+	const n = 10
+	values := make([]*ValueNode, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := client.Get("mykey")
+			if err != nil {
+				t.Errorf("Get failed: %v", err)
+			}
+			values[i] = value
+		}()
+	}
+	waitForWaiters(t, client, n-1)
+	close(release)
+	wg.Wait()
+
+	if got := mock.CallCount("SearchPosts") - searches; got != 1 {
+		t.Errorf("Expected 1 search, got %d", got)
+	}
+	if got := mock.CallCount("GetPost") - fetches; got != 1 {
+		t.Errorf("Expected 1 post fetch, got %d", got)
+	}
+
+	// Callers get their own copy
+	values[0].Value = "changed"
+	for _, value := range values[1:] {
+		if value.Value != "root" {
+			t.Errorf("Expected 'root', got '%s'", value.Value)
+		}
+	}
+}
+
+func TestConcurrentExistsCoalesced(t *testing.T) {
+	mock := NewMockRedditAPI()
+	_ = NewWithAPI(mock, "testsubreddit").Set("mykey", "root")
+	searches := mock.CallCount("SearchPosts")
+
+	release := make(chan struct{})
+	client := NewWithAPI(mock, "testsubreddit", WithMiddleware(gated(release)))
+
+	const n = 5
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if exists, err := client.Exists("mykey"); err != nil || !exists {
+				t.Errorf("Expected key to exist, got %v, %v", exists, err)
+			}
+		}()
+	}
+	waitForWaiters(t, client, n-1)
+	close(release)
+	wg.Wait()
+
+	if got := mock.CallCount("SearchPosts") - searches; got != 1 {
+		t.Errorf("Expected 1 search, got %d", got)
+	}
+}
+
+func TestReadAfterWriteNotCoalesced(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit")
+
+	// A Get still in flight from before the Set must not be joined
+	before := client.flightKey("Get", "mykey")
+	_ = client.Set("mykey", "root")
+	if client.flightKey("Get", "mykey") == before {
+		t.Error("Expected a finished write to start a new flight")
+	}
+}
+
+func TestClientConcurrentUse(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit", WithCache(CacheConfig{ValueTTL: time.Hour}))
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := fmt.Sprintf("key%d", i%2)
+			for j := range 5 {
+				_ = client.Set(key, fmt.Sprintf("value%d", j))
+				_ = client.Append(key, "child", []int{0})
+				_, _ = client.Get(key)
+				_, _ = client.Exists(key)
+				_, _ = client.Keys()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Each goroutine's last Set leaves a single value behind
+	for _, key := range []string{"key0", "key1"} {
+		if _, err := client.Get(key); err != nil {
+			t.Errorf("Get failed: %v", err)
+		}
+	}
+}
+
+func TestFlightPanicReleasesWaiters(t *testing.T) {
+	var g flightGroup
+	joined := make(chan struct{})
+
+	waiterErr := make(chan error, 1)
+	go func() {
+		<-joined
+		_, err, _ := g.do("key", func() (any, error) { return nil, nil })
+		waiterErr <- err
+	}()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected the panic to reach the caller")
+			}
+		}()
+		_, _, _ = g.do("key", func() (any, error) {
+			close(joined)
+			for {
+				g.mu.Lock()
+				dups := g.flights["key"].dups
+				g.mu.Unlock()
+				if dups == 1 {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+			panic("boom")
+		})
+	}()
+
+	select {
+	case err := <-waiterErr:
+		if err == nil {
+			t.Error("Expected the waiter to get an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Waiter is still blocked")
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !unix
+
+package redditkv
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Advisory locking is only implemented on Unix. Elsewhere the lock is
+// the file itself, created exclusively and removed on release.
+const (
+	lockRetryInterval = 10 * time.Millisecond
+	lockTimeout       = 30 * time.Second
+)
+
+// lockFile takes an exclusive lock by creating path, waiting while
+// another process holds it. The returned function releases the lock.
+// A process that crashes leaves the file behind; after lockTimeout the
+// error names it so it can be removed by hand.
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0600)
+		if err == nil {
+			f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is held by another process; remove it if none is running", path)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
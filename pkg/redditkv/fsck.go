@@ -0,0 +1,191 @@
+package redditkv
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// RepairPolicy decides which copy of a duplicated key survives a repair.
+type RepairPolicy int
+
+const (
+	// KeepNewest keeps the most recently created post.
+	KeepNewest RepairPolicy = iota
+	// KeepOldest keeps the first post created for the key.
+	KeepOldest
+	// KeepMostComments keeps the post with the largest value tree.
+	KeepMostComments
+)
+
+// ParseRepairPolicy converts a policy name ("newest", "oldest" or "most-comments").
+func ParseRepairPolicy(name string) (RepairPolicy, error) {
+	switch name {
+	case "newest":
+		return KeepNewest, nil
+	case "oldest":
+		return KeepOldest, nil
+	case "most-comments":
+		return KeepMostComments, nil
+	}
+	return 0, fmt.Errorf("unknown repair policy: %s", name)
+}
+
+// PostRef identifies a single post backing a key.
+type PostRef struct {
+	Key    string `json:"key"`
+	PostID string `json:"post_id"`
+}
+
+// DuplicateKey lists the posts that share a key, newest first.
+type DuplicateKey struct {
+	Key     string   `json:"key"`
+	PostIDs []string `json:"post_ids"`
+}
+
+// CheckReport describes the consistency problems found by Check.
+type CheckReport struct {
+	// Duplicates are keys backed by more than one post.
+	Duplicates []DuplicateKey `json:"duplicates"`
+	// Empty are posts that have no value comment.
+	Empty []PostRef `json:"empty"`
+	// Unreachable are posts that don't show up when searching for their key.
+	Unreachable []PostRef `json:"unreachable"`
+	// Deleted are the posts removed by Repair.
+	Deleted []PostRef `json:"deleted,omitempty"`
+}
+
+// OK reports whether no problems were found.
+func (r *CheckReport) OK() bool {
+	return len(r.Duplicates) == 0 && len(r.Empty) == 0 && len(r.Unreachable) == 0
+}
+
+// Check scans every post in the store for duplicate keys, posts without a
+// value comment, and posts that can't be found by searching for their key.
+func (c *KVClient) Check() (*CheckReport, error) {
+	report, _, err := c.check()
+	return report, err
+}
+
+// Repair runs Check and deletes all but one post of each duplicated key.
+// Posts that have a value are preferred over empty ones; the policy picks
+// among the rest.
+func (c *KVClient) Repair(policy RepairPolicy) (*CheckReport, error) {
+	defer c.purge()
+
+	report, byKey, err := c.check()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dup := range report.Duplicates {
+		posts := byKey[dup.Key]
+		keep := pickSurvivor(posts, policy)
+		for _, post := range posts {
+			if post == keep {
+				continue
+			}
+			if err := c.api.DeletePost(c.ctx, post.ID); err != nil {
+				return report, fmt.Errorf("failed to delete duplicate post %s: %w", post.ID, err)
+			}
+			report.Deleted = append(report.Deleted, PostRef{Key: dup.Key, PostID: post.ID})
+		}
+	}
+
+	return report, nil
+}
+
+// check builds the report and returns the posts grouped by key.
+func (c *KVClient) check() (*CheckReport, map[string][]*reddit.Post, error) {
+	posts, err := c.listAllPosts()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &CheckReport{}
+	byKey := make(map[string][]*reddit.Post)
+	var order []string
+	for _, post := range posts {
+		if _, seen := byKey[post.Title]; !seen {
+			order = append(order, post.Title)
+		}
+		byKey[post.Title] = append(byKey[post.Title], post)
+
+		if post.NumberOfComments == 0 {
+			report.Empty = append(report.Empty, PostRef{Key: post.Title, PostID: post.ID})
+		}
+	}
+
+	for _, key := range order {
+		keyPosts := byKey[key]
+		if len(keyPosts) > 1 {
+			dup := DuplicateKey{Key: key}
+			for _, post := range keyPosts {
+				dup.PostIDs = append(dup.PostIDs, post.ID)
+			}
+			report.Duplicates = append(report.Duplicates, dup)
+		}
+
+		found, err := c.api.SearchPosts(c.ctx, c.subreddit, key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to search for key %s: %w", key, err)
+		}
+		reachable := make(map[string]bool, len(found))
+		for _, post := range found {
+			reachable[post.ID] = true
+		}
+		for _, post := range keyPosts {
+			if !reachable[post.ID] {
+				report.Unreachable = append(report.Unreachable, PostRef{Key: key, PostID: post.ID})
+			}
+		}
+	}
+
+	return report, byKey, nil
+}
+
+// listAllPosts pages through the subreddit's listing, newest first, until
+// it runs out.
+func (c *KVClient) listAllPosts() ([]*reddit.Post, error) {
+	var posts []*reddit.Post
+	opts := &reddit.ListOptions{Limit: 100}
+	for {
+		page, err := c.api.ListNewPosts(c.ctx, c.subreddit, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list posts: %w", err)
+		}
+		if len(page) == 0 {
+			return posts, nil
+		}
+		posts = append(posts, page...)
+		opts = &reddit.ListOptions{Limit: 100, After: page[len(page)-1].FullID}
+	}
+}
+
+// pickSurvivor chooses the post to keep out of a set of duplicates.
+// posts must be ordered newest first, as returned by the listing.
+func pickSurvivor(posts []*reddit.Post, policy RepairPolicy) *reddit.Post {
+	candidates := make([]*reddit.Post, 0, len(posts))
+	for _, post := range posts {
+		if post.NumberOfComments > 0 {
+			candidates = append(candidates, post)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = posts
+	}
+
+	switch policy {
+	case KeepOldest:
+		return candidates[len(candidates)-1]
+	case KeepMostComments:
+		sorted := append([]*reddit.Post(nil), candidates...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].NumberOfComments > sorted[j].NumberOfComments
+		})
+		return sorted[0]
+	default:
+		return candidates[0]
+	}
+}
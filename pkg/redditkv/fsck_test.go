@@ -0,0 +1,118 @@
+package redditkv
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCheckFindsProblems(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	// Simulate two writers racing on the same key
+	_ = client.Set("dup", "first")
+	second, _ := mock.SubmitPost(client.ctx, "testsubreddit", "dup", "")
+	_, _ = mock.SubmitComment(client.ctx, second.FullID, "second")
+
+	// A Set that died between creating the post and the comment
+	empty, _ := mock.SubmitPost(client.ctx, "testsubreddit", "empty", "")
+
+	_ = client.Set("hidden", "value")
+	hidden, _ := client.findPostByTitle("hidden")
+	mock.HideFromSearch(hidden.ID)
+
+	report, err := client.Check()
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if report.OK() {
+		t.Fatal("Expected problems to be reported")
+	}
+
+	if len(report.Duplicates) != 1 || report.Duplicates[0].Key != "dup" {
+		t.Fatalf("Expected duplicate key 'dup', got %+v", report.Duplicates)
+	}
+	if len(report.Duplicates[0].PostIDs) != 2 {
+		t.Errorf("Expected 2 duplicate posts, got %d", len(report.Duplicates[0].PostIDs))
+	}
+
+	if len(report.Empty) != 1 || report.Empty[0].PostID != empty.ID {
+		t.Errorf("Expected empty post %s, got %+v", empty.ID, report.Empty)
+	}
+
+	if len(report.Unreachable) != 1 || report.Unreachable[0].PostID != hidden.ID {
+		t.Errorf("Expected unreachable post %s, got %+v", hidden.ID, report.Unreachable)
+	}
+}
+
+func TestCheckPagesThroughListing(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	// The duplicate is well past the first page of the listing
+	_ = client.Set("old", "first")
+	second, _ := mock.SubmitPost(client.ctx, "testsubreddit", "old", "")
+	_, _ = mock.SubmitComment(client.ctx, second.FullID, "second")
+	for i := range 250 {
+		_ = client.Set(fmt.Sprintf("key%d", i), "value")
+	}
+
+	report, err := client.Check()
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(report.Duplicates) != 1 || report.Duplicates[0].Key != "old" {
+		t.Errorf("Expected duplicate key 'old', got %+v", report.Duplicates)
+	}
+	if len(report.Unreachable) != 0 {
+		t.Errorf("Expected no unreachable posts, got %+v", report.Unreachable)
+	}
+}
+
+func TestRepairKeepsNewest(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	_ = client.Set("dup", "first")
+	second, _ := mock.SubmitPost(client.ctx, "testsubreddit", "dup", "")
+	_, _ = mock.SubmitComment(client.ctx, second.FullID, "second")
+
+	report, err := client.Repair(KeepNewest)
+	if err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+	if len(report.Deleted) != 1 {
+		t.Fatalf("Expected 1 deleted post, got %d", len(report.Deleted))
+	}
+	if mock.GetPostCount() != 1 {
+		t.Errorf("Expected 1 post after repair, got %d", mock.GetPostCount())
+	}
+
+	value, err := client.Get("dup")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "second" {
+		t.Errorf("Expected value 'second', got '%s'", value.Value)
+	}
+}
+
+func TestRepairPrefersPostsWithValue(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	_ = client.Set("dup", "first")
+	_, _ = mock.SubmitPost(client.ctx, "testsubreddit", "dup", "")
+
+	if _, err := client.Repair(KeepNewest); err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+
+	value, err := client.Get("dup")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "first" {
+		t.Errorf("Expected value 'first', got '%s'", value.Value)
+	}
+}
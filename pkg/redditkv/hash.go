@@ -0,0 +1,225 @@
+package redditkv
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// hashType is the data type of hash keys.
+const hashType = "hash"
+
+// Hashes are keys whose top-level comments are fields, one per comment,
+// with bodies of the form "field: value". The value is everything after
+// the first ": ", verbatim. In field names, "%", ":" and newlines are
+// written as "%25", "%3A" and "%0A", so a field can never be mistaken for
+// part of its value. Get returns a hash as a plain value tree of these
+// bodies, and Append(key, "field: value", nil) adds a field.
+//
+// HSet edits a field's comment in place. If writers race to add the same
+// field, the newest comment wins and HDel removes them all. Comments that
+// aren't fields are ignored.
+
+// hashField is one field of a hash and the comments holding it, oldest
+// first. The newest comment holds the field's value.
+type hashField struct {
+	name     string
+	value    string
+	comments []*reddit.Comment
+}
+
+// HSet sets a field in a hash, creating the hash if it doesn't exist yet.
+// HSet fails with a WrongTypeError on keys that aren't hashes.
+func (c *KVClient) HSet(key, field, value string) error {
+	defer c.invalidate(key)
+
+	body := encodeField(field, value)
+	pc, created, err := c.typedPost(key, hashType, body)
+	if err != nil || created {
+		return err
+	}
+
+	for _, f := range parseHash(pc.Comments) {
+		if f.name != field {
+			continue
+		}
+		if f.value == value {
+			return nil
+		}
+		err := c.editField(key, pc.Post, f, body)
+		var archived *ArchivedKeyError
+		if !c.autoMigrate || !errors.As(err, &archived) {
+			return err
+		}
+		// The newest comment for a field wins, so appending to the
+		// migrated key overrides the old value
+		break
+	}
+
+	_, err = c.appendToPost(key, pc, body, nil)
+	return err
+}
+
+// editField rewrites the newest comment holding a field.
+func (c *KVClient) editField(key string, post *reddit.Post, f *hashField, body string) error {
+	if err := checkWritable(key, post); err != nil {
+		return err
+	}
+
+	newest := f.comments[len(f.comments)-1]
+	if _, err := c.api.EditComment(c.ctx, newest.FullID, body); err != nil {
+		if stateErr := classifyWriteError(key, err); stateErr != nil {
+			return stateErr
+		}
+		return fmt.Errorf("failed to set field: %w", err)
+	}
+	return nil
+}
+
+// HGet returns a field of a hash. It fails with a FieldNotFoundError if
+// the hash has no such field.
+func (c *KVClient) HGet(key, field string) (string, error) {
+	fields, err := c.hashFields(key)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range fields {
+		if f.name == field {
+			return f.value, nil
+		}
+	}
+	return "", &FieldNotFoundError{Key: key, Field: field}
+}
+
+// HDel removes fields from a hash and returns how many of them existed.
+func (c *KVClient) HDel(key string, fields ...string) (int, error) {
+	defer c.invalidate(key)
+
+	existing, err := c.hashFields(key)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, f := range existing {
+		for _, field := range fields {
+			if f.name != field {
+				continue
+			}
+			for _, comment := range f.comments {
+				if err := c.api.DeleteComment(c.ctx, comment.FullID); err != nil {
+					return removed, fmt.Errorf("failed to delete field: %w", err)
+				}
+			}
+			removed++
+			break
+		}
+	}
+	return removed, nil
+}
+
+// HGetAll returns every field of a hash.
+func (c *KVClient) HGetAll(key string) (map[string]string, error) {
+	fields, err := c.hashFields(key)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make(map[string]string, len(fields))
+	for _, f := range fields {
+		all[f.name] = f.value
+	}
+	return all, nil
+}
+
+// HKeys returns the field names of a hash, in the order they were added.
+func (c *KVClient) HKeys(key string) ([]string, error) {
+	fields, err := c.hashFields(key)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	return names, nil
+}
+
+// hashFields reads a hash's fields.
+func (c *KVClient) hashFields(key string) ([]*hashField, error) {
+	post, err := c.findOldestPost(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find key: %w", err)
+	}
+	if post == nil {
+		return nil, &KeyNotFoundError{Key: key}
+	}
+	if keyType(post) != hashType {
+		return nil, &WrongTypeError{Key: key, Type: hashType}
+	}
+
+	postAndComments, err := c.api.GetPost(c.ctx, post.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	return parseHash(postAndComments.Comments), nil
+}
+
+// parseHash returns the fields in top-level comments, in the order they
+// were first added. Later comments for a field override earlier ones.
+func parseHash(comments []*reddit.Comment) []*hashField {
+	sorted := slices.Clone(comments)
+	sort.Slice(sorted, func(i, j int) bool {
+		return idLess(sorted[i].ID, sorted[j].ID)
+	})
+
+	var fields []*hashField
+	byName := make(map[string]*hashField)
+	for _, comment := range sorted {
+		if isRemovedComment(comment) {
+			continue
+		}
+		name, value, ok := decodeField(comment.Body)
+		if !ok {
+			continue
+		}
+
+		f, seen := byName[name]
+		if !seen {
+			f = &hashField{name: name}
+			byName[name] = f
+			fields = append(fields, f)
+		}
+		f.comments = append(f.comments, comment)
+		f.value = value
+	}
+	return fields
+}
+
+var fieldEscaper = strings.NewReplacer("%", "%25", ":", "%3A", "\n", "%0A")
+
+func encodeField(field, value string) string {
+	return fieldEscaper.Replace(field) + ": " + value
+}
+
+func decodeField(body string) (field, value string, ok bool) {
+	escaped, value, ok := strings.Cut(body, ": ")
+	if !ok {
+		// Reddit trims trailing spaces, so empty values end in ":"
+		escaped, ok = strings.CutSuffix(body, ":")
+		if !ok || strings.Contains(escaped, ":") {
+			return "", "", false
+		}
+	}
+	field, err := url.PathUnescape(escaped)
+	if err != nil {
+		return "", "", false
+	}
+	return field, value, true
+}
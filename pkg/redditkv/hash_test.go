@@ -0,0 +1,128 @@
+package redditkv
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	if err := client.HSet("user:1", "name", "alice"); err != nil {
+		t.Fatalf("HSet failed: %v", err)
+	}
+	_ = client.HSet("user:1", "email", "alice@example.com")
+	_ = client.HSet("user:1", "note", "a: b")
+	_ = client.HSet("user:1", "odd:name", "")
+
+	// Existing fields are edited in place
+	if err := client.HSet("user:1", "name", "bob"); err != nil {
+		t.Fatalf("HSet failed: %v", err)
+	}
+	if mock.GetCommentCount() != 4 {
+		t.Errorf("Expected one comment per field, got %d", mock.GetCommentCount())
+	}
+
+	name, err := client.HGet("user:1", "name")
+	if err != nil {
+		t.Fatalf("HGet failed: %v", err)
+	}
+	if name != "bob" {
+		t.Errorf("Expected 'bob', got '%s'", name)
+	}
+
+	all, err := client.HGetAll("user:1")
+	if err != nil {
+		t.Fatalf("HGetAll failed: %v", err)
+	}
+	if all["note"] != "a: b" || all["odd:name"] != "" || len(all) != 4 {
+		t.Errorf("Unexpected fields: %v", all)
+	}
+
+	keys, _ := client.HKeys("user:1")
+	if got := fmt.Sprint(keys); got != "[name email note odd:name]" {
+		t.Errorf("Expected fields in insertion order, got %s", got)
+	}
+
+	// Plain Get sees the encoded fields
+	value, _ := client.Get("user:1")
+	if value.Value != "name: bob" || value.Children[2].Value != "odd%3Aname: " {
+		t.Errorf("Unexpected value tree: %+v", value)
+	}
+
+	removed, err := client.HDel("user:1", "email", "missing")
+	if err != nil {
+		t.Fatalf("HDel failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 field removed, got %d", removed)
+	}
+
+	var fieldNotFound *FieldNotFoundError
+	if _, err := client.HGet("user:1", "email"); !errors.As(err, &fieldNotFound) {
+		t.Errorf("Expected FieldNotFoundError, got %v", err)
+	}
+
+	_ = client.Set("plain", "value")
+	var wrongType *WrongTypeError
+	if err := client.HSet("plain", "field", "value"); !errors.As(err, &wrongType) {
+		t.Errorf("Expected WrongTypeError, got %v", err)
+	}
+}
+
+func TestHashAppendedFields(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit")
+	_ = client.HSet("config", "mode", "fast")
+
+	// Fields appended by hand follow the same encoding; the newest wins
+	_ = client.Append("config", "mode: safe", nil)
+	_ = client.Append("config", "not a field", nil)
+
+	all, err := client.HGetAll("config")
+	if err != nil {
+		t.Fatalf("HGetAll failed: %v", err)
+	}
+	if len(all) != 1 || all["mode"] != "safe" {
+		t.Errorf("Expected mode=safe, got %v", all)
+	}
+
+	if removed, _ := client.HDel("config", "mode"); removed != 1 {
+		t.Errorf("Expected 1 field removed, got %d", removed)
+	}
+	if all, _ := client.HGetAll("config"); len(all) != 0 {
+		t.Errorf("Expected every copy of the field to be removed, got %v", all)
+	}
+}
+
+func TestHSetArchived(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+	_ = client.HSet("user", "name", "ada")
+	post, _ := client.findPostByTitle("user")
+	mock.SetPostArchived(post.ID, true)
+
+	var archived *ArchivedKeyError
+	if err := client.HSet("user", "name", "grace"); !errors.As(err, &archived) {
+		t.Fatalf("Expected ArchivedKeyError, got %v", err)
+	}
+
+	migrating := NewWithAPI(mock, "testsubreddit", WithAutoMigrate())
+	if err := migrating.HSet("user", "name", "grace"); err != nil {
+		t.Fatalf("HSet with auto-migrate failed: %v", err)
+	}
+	if name, _ := migrating.HGet("user", "name"); name != "grace" {
+		t.Errorf("Expected grace, got %q", name)
+	}
+	if n := mock.GetPostCount(); n != 1 {
+		t.Errorf("Expected the key to move to a new post, got %d posts", n)
+	}
+
+	post, _ = client.findPostByTitle("user")
+	mock.SetPostLocked(post.ID, true)
+	var locked *LockedKeyError
+	if err := client.HSet("user", "name", "ada"); !errors.As(err, &locked) {
+		t.Errorf("Expected LockedKeyError, got %v", err)
+	}
+}
@@ -0,0 +1,296 @@
+package redditkv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// LocalRedditAPI is a RedditAPI that keeps posts and comments in a local
+// JSON file, for working offline and for exercising the CLI in CI.
+//
+// Every call reloads the file under an advisory lock and writes it back
+// atomically after changes, so several processes can share one database.
+type LocalRedditAPI struct {
+	path string
+	mu   sync.Mutex
+}
+
+// localDB is the on-disk format. Comments are stored flat, in creation
+// order, and linked to their parent by ID.
+type localDB struct {
+	NextID   int            `json:"next_id"`
+	Posts    []localPost    `json:"posts"`
+	Comments []localComment `json:"comments"`
+}
+
+type localPost struct {
+	ID          string    `json:"id"`
+	Subreddit   string    `json:"subreddit"`
+	Title       string    `json:"title"`
+	Body        string    `json:"body,omitempty"`
+	Created     time.Time `json:"created"`
+	Locked      bool      `json:"locked,omitempty"`
+	NumComments int       `json:"num_comments"`
+}
+
+type localComment struct {
+	ID       string     `json:"id"`
+	ParentID string     `json:"parent_id"`
+	PostID   string     `json:"post_id"`
+	Body     string     `json:"body"`
+	Author   string     `json:"author,omitempty"`
+	Created  time.Time  `json:"created"`
+	Edited   *time.Time `json:"edited,omitempty"`
+}
+
+// NewLocalRedditAPI opens (or creates on first write) a local database file.
+func NewLocalRedditAPI(path string) (*LocalRedditAPI, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+	return &LocalRedditAPI{path: path}, nil
+}
+
+// with loads the database into an in-memory mock, runs fn against it, and
+// saves the result if write is set and fn succeeded.
+func (l *LocalRedditAPI) with(write bool, fn func(m *MockRedditAPI) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	unlock, err := lockFile(l.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock database: %w", err)
+	}
+	defer unlock()
+
+	m, err := l.load()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(m); err != nil {
+		return err
+	}
+
+	if write {
+		return l.save(m)
+	}
+	return nil
+}
+
+func (l *LocalRedditAPI) load() (*MockRedditAPI, error) {
+	m := NewMockRedditAPI()
+
+	data, err := os.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database: %w", err)
+	}
+
+	var db localDB
+	if err := json.Unmarshal(data, &db); err != nil {
+		return nil, fmt.Errorf("failed to parse database: %w", err)
+	}
+
+	m.idCounter = db.NextID
+	for _, p := range db.Posts {
+		created := reddit.Timestamp{Time: p.Created}
+		m.posts[p.ID] = &mockPost{
+			post: &reddit.Post{
+				ID:               p.ID,
+				FullID:           "t3_" + p.ID,
+				Title:            p.Title,
+				Body:             p.Body,
+				SubredditName:    p.Subreddit,
+				Created:          &created,
+				Locked:           p.Locked,
+				NumberOfComments: p.NumComments,
+			},
+			comments: []*reddit.Comment{},
+		}
+	}
+
+	for _, c := range db.Comments {
+		created := reddit.Timestamp{Time: c.Created}
+		comment := &reddit.Comment{
+			ID:       c.ID,
+			FullID:   "t1_" + c.ID,
+			ParentID: c.ParentID,
+			PostID:   c.PostID,
+			Body:     c.Body,
+			Author:   c.Author,
+			Created:  &created,
+			Replies:  reddit.Replies{Comments: []*reddit.Comment{}},
+		}
+		if c.Edited != nil {
+			comment.Edited = &reddit.Timestamp{Time: *c.Edited}
+		}
+		m.comments[c.ID] = comment
+
+		if strings.HasPrefix(c.ParentID, "t3_") {
+			if mp, ok := m.posts[c.ParentID[3:]]; ok {
+				mp.comments = append(mp.comments, comment)
+			}
+		} else if parent, ok := m.comments[strings.TrimPrefix(c.ParentID, "t1_")]; ok {
+			parent.Replies.Comments = append(parent.Replies.Comments, comment)
+		}
+	}
+
+	return m, nil
+}
+
+func (l *LocalRedditAPI) save(m *MockRedditAPI) error {
+	db := localDB{NextID: m.idCounter}
+
+	for _, mp := range m.posts {
+		p := mp.post
+		db.Posts = append(db.Posts, localPost{
+			ID:          p.ID,
+			Subreddit:   p.SubredditName,
+			Title:       p.Title,
+			Body:        p.Body,
+			Created:     p.Created.Time,
+			Locked:      p.Locked,
+			NumComments: p.NumberOfComments,
+		})
+	}
+	sort.Slice(db.Posts, func(i, j int) bool {
+		a, _ := strconv.Atoi(db.Posts[i].ID)
+		b, _ := strconv.Atoi(db.Posts[j].ID)
+		return a < b
+	})
+
+	// Comments of deleted posts are dropped here
+	for _, c := range m.comments {
+		if _, ok := m.posts[strings.TrimPrefix(c.PostID, "t3_")]; !ok {
+			continue
+		}
+		lc := localComment{
+			ID:       c.ID,
+			ParentID: c.ParentID,
+			PostID:   c.PostID,
+			Body:     c.Body,
+			Author:   c.Author,
+			Created:  c.Created.Time,
+		}
+		if c.Edited != nil {
+			edited := c.Edited.Time
+			lc.Edited = &edited
+		}
+		db.Comments = append(db.Comments, lc)
+	}
+	sort.Slice(db.Comments, func(i, j int) bool {
+		a, _ := strconv.Atoi(db.Comments[i].ID)
+		b, _ := strconv.Atoi(db.Comments[j].ID)
+		return a < b
+	})
+
+	data, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode database: %w", err)
+	}
+
+	// Write to a temp file and rename so readers never see a partial file
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write database: %w", err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return fmt.Errorf("failed to write database: %w", err)
+	}
+	return nil
+}
+
+func (l *LocalRedditAPI) SubmitPost(ctx context.Context, subreddit, title, text string) (*reddit.Submitted, error) {
+	var submitted *reddit.Submitted
+	err := l.with(true, func(m *MockRedditAPI) error {
+		var err error
+		submitted, err = m.SubmitPost(ctx, subreddit, title, text)
+		return err
+	})
+	return submitted, err
+}
+
+func (l *LocalRedditAPI) GetPost(ctx context.Context, postID string) (*reddit.PostAndComments, error) {
+	var post *reddit.PostAndComments
+	err := l.with(false, func(m *MockRedditAPI) error {
+		var err error
+		post, err = m.GetPost(ctx, postID)
+		return err
+	})
+	return post, err
+}
+
+func (l *LocalRedditAPI) GetPosts(ctx context.Context, postIDs []string) ([]*reddit.Post, error) {
+	var posts []*reddit.Post
+	err := l.with(false, func(m *MockRedditAPI) error {
+		var err error
+		posts, err = m.GetPosts(ctx, postIDs)
+		return err
+	})
+	return posts, err
+}
+
+func (l *LocalRedditAPI) DeletePost(ctx context.Context, postID string) error {
+	return l.with(true, func(m *MockRedditAPI) error {
+		return m.DeletePost(ctx, postID)
+	})
+}
+
+func (l *LocalRedditAPI) SubmitComment(ctx context.Context, parentID, text string) (*reddit.Comment, error) {
+	var comment *reddit.Comment
+	err := l.with(true, func(m *MockRedditAPI) error {
+		var err error
+		comment, err = m.SubmitComment(ctx, parentID, text)
+		return err
+	})
+	return comment, err
+}
+
+func (l *LocalRedditAPI) EditComment(ctx context.Context, commentID, text string) (*reddit.Comment, error) {
+	var comment *reddit.Comment
+	err := l.with(true, func(m *MockRedditAPI) error {
+		var err error
+		comment, err = m.EditComment(ctx, commentID, text)
+		return err
+	})
+	return comment, err
+}
+
+func (l *LocalRedditAPI) DeleteComment(ctx context.Context, commentID string) error {
+	return l.with(true, func(m *MockRedditAPI) error {
+		return m.DeleteComment(ctx, commentID)
+	})
+}
+
+func (l *LocalRedditAPI) ListNewPosts(ctx context.Context, subreddit string, opts *reddit.ListOptions) ([]*reddit.Post, error) {
+	var posts []*reddit.Post
+	err := l.with(false, func(m *MockRedditAPI) error {
+		var err error
+		posts, err = m.ListNewPosts(ctx, subreddit, opts)
+		return err
+	})
+	return posts, err
+}
+
+func (l *LocalRedditAPI) SearchPosts(ctx context.Context, subreddit, query string) ([]*reddit.Post, error) {
+	var posts []*reddit.Post
+	err := l.with(false, func(m *MockRedditAPI) error {
+		var err error
+		posts, err = m.SearchPosts(ctx, subreddit, query)
+		return err
+	})
+	return posts, err
+}
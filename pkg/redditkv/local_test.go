@@ -0,0 +1,50 @@
+package redditkv
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalBackendPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kv.json")
+
+	client, err := New(Config{Backend: "local:" + path, Subreddit: "local"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	_ = client.Set("mykey", "root")
+	_ = client.Append("mykey", "child", []int{0})
+	_ = client.Set("other", "value")
+	_ = client.Delete("other")
+
+	// A second client (e.g. the next CLI invocation) sees the same data
+	reopened, err := New(Config{Backend: "local:" + path, Subreddit: "local"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	value, err := reopened.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "root" || len(value.Children) != 1 || value.Children[0].Value != "child" {
+		t.Errorf("Unexpected tree: %+v", value)
+	}
+
+	keys, _ := reopened.Keys()
+	if len(keys) != 1 || keys[0] != "mykey" {
+		t.Errorf("Expected only 'mykey', got %v", keys)
+	}
+
+	// New IDs must not collide with ones handed out before reopening
+	_ = reopened.Set("third", "value")
+	if value, err := reopened.Get("mykey"); err != nil || value.Value != "root" {
+		t.Errorf("Expected 'mykey' to be intact, got %+v, %v", value, err)
+	}
+}
+
+func TestUnknownBackend(t *testing.T) {
+	if _, err := New(Config{Backend: "postgres://nope"}); err == nil {
+		t.Error("Expected an error for an unknown backend")
+	}
+}
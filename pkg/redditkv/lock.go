@@ -0,0 +1,214 @@
+package redditkv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// lockKeyPrefix namespaces lock posts so they don't collide with user keys.
+const lockKeyPrefix = "_lock:"
+
+// Lease is a held distributed lock.
+//
+// Each lock is a post; each attempt to take it is a top-level claim comment
+// carrying the claimant and an expiry. The earliest unexpired claim holds
+// the lock. Comment IDs only ever grow, so the winning claim's ID doubles
+// as a fencing token that increases with every new holder.
+type Lease struct {
+	Name    string
+	Holder  string
+	Token   uint64
+	Expires time.Time
+
+	// ID is the claim comment's full ID; pass it to ResumeLease to act on
+	// the lease from another process.
+	ID string
+
+	client *KVClient
+	postID string
+}
+
+// leaseClaim is the JSON body of a claim comment.
+type leaseClaim struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+
+	comment *reddit.Comment
+}
+
+// Lock tries once to take the named lock for ttl. If someone else holds an
+// unexpired lease, a LockHeldError is returned.
+func (c *KVClient) Lock(name string, ttl time.Duration) (*Lease, error) {
+	post, err := c.lockPost(name)
+	if err != nil {
+		return nil, err
+	}
+
+	claim := leaseClaim{Holder: c.holderID(), Expires: time.Now().Add(ttl)}
+	body, err := json.Marshal(claim)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode claim: %w", err)
+	}
+
+	ours, err := c.api.SubmitComment(c.ctx, post.FullID, string(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit claim: %w", err)
+	}
+
+	claims, err := c.lockClaims(post.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, other := range claims {
+		if other.comment.ID == ours.ID {
+			break
+		}
+		if other.Expires.After(now) {
+			// Someone claimed first; withdraw ours
+			_ = c.api.DeleteComment(c.ctx, ours.FullID)
+			return nil, &LockHeldError{Name: name, Holder: other.Holder, Expires: other.Expires}
+		}
+		// Stale lease, clean it up
+		_ = c.api.DeleteComment(c.ctx, other.comment.FullID)
+	}
+
+	token, err := strconv.ParseUint(ours.ID, 36, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive fencing token: %w", err)
+	}
+
+	return &Lease{
+		Name:    name,
+		Holder:  claim.Holder,
+		Token:   token,
+		Expires: claim.Expires,
+		ID:      ours.FullID,
+		client:  c,
+		postID:  post.ID,
+	}, nil
+}
+
+// ResumeLease looks up a lease previously returned by Lock, so another
+// process can refresh or release it.
+func (c *KVClient) ResumeLease(name, leaseID string) (*Lease, error) {
+	post, err := c.findPostByTitle(lockKeyPrefix + name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find lock: %w", err)
+	}
+	if post == nil {
+		return nil, &LeaseLostError{Name: name}
+	}
+
+	claims, err := c.lockClaims(post.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, claim := range claims {
+		if claim.comment.FullID != leaseID {
+			continue
+		}
+		token, err := strconv.ParseUint(claim.comment.ID, 36, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive fencing token: %w", err)
+		}
+		return &Lease{
+			Name:    name,
+			Holder:  claim.Holder,
+			Token:   token,
+			Expires: claim.Expires,
+			ID:      leaseID,
+			client:  c,
+			postID:  post.ID,
+		}, nil
+	}
+
+	return nil, &LeaseLostError{Name: name}
+}
+
+// Refresh extends the lease to ttl from now. It fails with a LeaseLostError
+// if the lease already expired, since another claimant may have taken over.
+func (l *Lease) Refresh(ttl time.Duration) error {
+	c := l.client
+	claims, err := c.lockClaims(l.postID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, claim := range claims {
+		if claim.comment.FullID != l.ID {
+			continue
+		}
+		if !claim.Expires.After(now) {
+			_ = c.api.DeleteComment(c.ctx, l.ID)
+			return &LeaseLostError{Name: l.Name}
+		}
+
+		claim.Expires = now.Add(ttl)
+		body, err := json.Marshal(claim)
+		if err != nil {
+			return fmt.Errorf("failed to encode claim: %w", err)
+		}
+		if _, err := c.api.EditComment(c.ctx, l.ID, string(body)); err != nil {
+			return fmt.Errorf("failed to refresh lease: %w", err)
+		}
+		l.Expires = claim.Expires
+		return nil
+	}
+
+	return &LeaseLostError{Name: l.Name}
+}
+
+// Unlock releases the lease.
+func (l *Lease) Unlock() error {
+	if err := l.client.api.DeleteComment(l.client.ctx, l.ID); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+// lockPost finds or creates the post backing a lock.
+func (c *KVClient) lockPost(name string) (*reddit.Post, error) {
+	return c.bookkeepingPost(lockKeyPrefix+name, "lock")
+}
+
+// lockClaims returns the claims on a lock post in creation order.
+// Comments that aren't claims are skipped.
+func (c *KVClient) lockClaims(postID string) ([]*leaseClaim, error) {
+	postAndComments, err := c.api.GetPost(c.ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock: %w", err)
+	}
+
+	var claims []*leaseClaim
+	for _, comment := range postAndComments.Comments {
+		var claim leaseClaim
+		if err := json.Unmarshal([]byte(comment.Body), &claim); err != nil || claim.Holder == "" {
+			continue
+		}
+		claim.comment = comment
+		claims = append(claims, &claim)
+	}
+
+	sort.Slice(claims, func(i, j int) bool {
+		return idLess(claims[i].comment.ID, claims[j].comment.ID)
+	})
+	return claims, nil
+}
+
+// holderID identifies this process in lease claims.
+func (c *KVClient) holderID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
@@ -0,0 +1,101 @@
+package redditkv
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockExclusive(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	lease, err := client.Lock("cron", time.Minute)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if lease.Token == 0 {
+		t.Error("Expected a fencing token")
+	}
+
+	_, err = client.Lock("cron", time.Minute)
+	if _, ok := err.(*LockHeldError); !ok {
+		t.Fatalf("Expected LockHeldError, got %T: %v", err, err)
+	}
+
+	if err := lease.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+
+	next, err := client.Lock("cron", time.Minute)
+	if err != nil {
+		t.Fatalf("Lock after Unlock failed: %v", err)
+	}
+	if next.Token <= lease.Token {
+		t.Errorf("Expected fencing token to increase, got %d after %d", next.Token, lease.Token)
+	}
+}
+
+func TestLockExpiry(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	stale, err := client.Lock("cron", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	lease, err := client.Lock("cron", time.Minute)
+	if err != nil {
+		t.Fatalf("Lock over expired lease failed: %v", err)
+	}
+	if lease.Token <= stale.Token {
+		t.Errorf("Expected fencing token to increase, got %d after %d", lease.Token, stale.Token)
+	}
+
+	// The stale holder must not be able to revive its lease
+	if err := stale.Refresh(time.Minute); err == nil {
+		t.Error("Expected Refresh of an expired lease to fail")
+	}
+}
+
+func TestLockRefresh(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	lease, err := client.Lock("cron", 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if err := lease.Refresh(time.Minute); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	if _, err := client.Lock("cron", time.Minute); err == nil {
+		t.Error("Expected refreshed lease to still be held")
+	}
+
+	resumed, err := client.ResumeLease("cron", lease.ID)
+	if err != nil {
+		t.Fatalf("ResumeLease failed: %v", err)
+	}
+	if resumed.Token != lease.Token {
+		t.Errorf("Expected token %d, got %d", lease.Token, resumed.Token)
+	}
+}
+
+func TestKeysHidesLocks(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	_ = client.Set("mykey", "value")
+	if _, err := client.Lock("cron", time.Minute); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+
+	keys, _ := client.Keys()
+	if len(keys) != 1 || keys[0] != "mykey" {
+		t.Errorf("Expected only 'mykey', got %v", keys)
+	}
+}
@@ -0,0 +1,102 @@
+package redditkv
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// Key metadata lives in the post body, which is otherwise unused, as a
+// JSON object of string fields. Posts with an empty or non-JSON body have
+// no metadata.
+
+func encodeMeta(meta map[string]string) (string, error) {
+	if len(meta) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	return string(data), nil
+}
+
+func decodeMeta(body string) map[string]string {
+	if body == "" {
+		return nil
+	}
+	var meta map[string]string
+	if err := json.Unmarshal([]byte(body), &meta); err != nil {
+		return nil
+	}
+	return meta
+}
+
+// typeMetaKey marks keys that hold a data type other than a plain value
+// tree, such as counters. Get reads such keys according to their type.
+const typeMetaKey = "_type"
+
+// keyType returns the data type recorded in a key's metadata, or "" for
+// a plain value tree.
+func keyType(post *reddit.Post) string {
+	return decodeMeta(post.Body)[typeMetaKey]
+}
+
+// typedPost fetches a key that must hold the given data type. If the key
+// doesn't exist, it is created holding first as its only comment, and
+// created is true. Otherwise first isn't written, and the caller adds it
+// to the returned post.
+func (c *KVClient) typedPost(key, kind, first string) (pc *reddit.PostAndComments, created bool, err error) {
+	post, err := c.findOldestPost(key)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find key: %w", err)
+	}
+
+	if post == nil {
+		submitted, err := c.replace(key, first, map[string]string{typeMetaKey: kind}, nil)
+		if err != nil {
+			return nil, false, err
+		}
+		won, err := c.resolveRace(key, submitted.ID)
+		if err != nil || won {
+			return nil, won, err
+		}
+
+		// A competing writer created it first; use theirs
+		post, err = c.findOldestPost(key)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to find key: %w", err)
+		}
+		if post == nil {
+			return nil, false, &KeyNotFoundError{Key: key}
+		}
+	}
+
+	pc, err = c.api.GetPost(c.ctx, post.ID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get post: %w", err)
+	}
+	if keyType(pc.Post) != kind {
+		return nil, false, &WrongTypeError{Key: key, Type: kind}
+	}
+	return pc, false, nil
+}
+
+// findOldestPost is like findPostByTitle, but if writers are racing to
+// create the key it picks the post resolveRace will keep, so that writes
+// made during the race aren't lost with a losing post.
+func (c *KVClient) findOldestPost(title string) (*reddit.Post, error) {
+	posts, err := c.api.SearchPosts(c.ctx, c.subreddit, title)
+	if err != nil {
+		return nil, err
+	}
+
+	var oldest *reddit.Post
+	for _, post := range posts {
+		if post.Title == title && (oldest == nil || idLess(post.ID, oldest.ID)) {
+			oldest = post
+		}
+	}
+	return oldest, nil
+}
@@ -0,0 +1,32 @@
+package redditkv
+
+import (
+	"testing"
+)
+
+func TestSetWithMeta(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	if err := client.SetWithMeta("mykey", "value", map[string]string{"flags": "42"}); err != nil {
+		t.Fatalf("SetWithMeta failed: %v", err)
+	}
+
+	value, err := client.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "value" {
+		t.Errorf("Expected value 'value', got '%s'", value.Value)
+	}
+	if value.Meta["flags"] != "42" {
+		t.Errorf("Expected flags '42', got %v", value.Meta)
+	}
+
+	// A plain Set clears the metadata
+	_ = client.Set("mykey", "plain")
+	value, _ = client.Get("mykey")
+	if value.Meta != nil {
+		t.Errorf("Expected no metadata, got %v", value.Meta)
+	}
+}
@@ -0,0 +1,208 @@
+package redditkv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// Call describes one RedditAPI call passing through a middleware chain.
+type Call struct {
+	// Op is the RedditAPI method name, e.g. "SubmitPost".
+	Op string
+	// Args are the method's arguments after the context, in order.
+	Args []any
+}
+
+// Invoker performs a RedditAPI call. The result is the method's first
+// return value, or nil for methods that only return an error.
+type Invoker func(ctx context.Context, call *Call) (any, error)
+
+// Middleware wraps an Invoker to add behavior around every RedditAPI
+// call, such as retries or logging.
+type Middleware func(next Invoker) Invoker
+
+// Chain wraps api so every call runs through the middlewares. The first
+// middleware is the outermost one.
+func Chain(api RedditAPI, middlewares ...Middleware) RedditAPI {
+	invoke := dispatch(api)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		invoke = middlewares[i](invoke)
+	}
+	return &chainedAPI{invoke: invoke}
+}
+
+// WithMiddleware installs a middleware chain around the client's API.
+// Repeated options nest, with earlier ones on the outside.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *KVClient) {
+		c.api = Chain(c.api, middlewares...)
+	}
+}
+
+// dispatch returns the Invoker at the end of a chain, which calls api.
+func dispatch(api RedditAPI) Invoker {
+	return func(ctx context.Context, call *Call) (any, error) {
+		a := call.Args
+		switch call.Op {
+		case "SubmitPost":
+			return api.SubmitPost(ctx, a[0].(string), a[1].(string), a[2].(string))
+		case "GetPost":
+			return api.GetPost(ctx, a[0].(string))
+		case "GetPosts":
+			return api.GetPosts(ctx, a[0].([]string))
+		case "DeletePost":
+			return nil, api.DeletePost(ctx, a[0].(string))
+		case "SubmitComment":
+			return api.SubmitComment(ctx, a[0].(string), a[1].(string))
+		case "EditComment":
+			return api.EditComment(ctx, a[0].(string), a[1].(string))
+		case "DeleteComment":
+			return nil, api.DeleteComment(ctx, a[0].(string))
+		case "ListNewPosts":
+			return api.ListNewPosts(ctx, a[0].(string), a[1].(*reddit.ListOptions))
+		case "SearchPosts":
+			return api.SearchPosts(ctx, a[0].(string), a[1].(string))
+		}
+		return nil, fmt.Errorf("unknown operation: %s", call.Op)
+	}
+}
+
+// chainedAPI adapts an Invoker back to the RedditAPI interface.
+type chainedAPI struct {
+	invoke Invoker
+}
+
+// result converts an Invoker's result back to the method's return type.
+// A middleware that short-circuits a call with a nil result gets a nil
+// value of the right type; any other mismatch is an error.
+func result[T any](v any, err error) (T, error) {
+	t, ok := v.(T)
+	if !ok && v != nil {
+		return t, fmt.Errorf("middleware returned %T, want %T", v, t)
+	}
+	return t, err
+}
+
+func (c *chainedAPI) SubmitPost(ctx context.Context, subreddit, title, text string) (*reddit.Submitted, error) {
+	return result[*reddit.Submitted](c.invoke(ctx, &Call{Op: "SubmitPost", Args: []any{subreddit, title, text}}))
+}
+
+func (c *chainedAPI) GetPost(ctx context.Context, postID string) (*reddit.PostAndComments, error) {
+	return result[*reddit.PostAndComments](c.invoke(ctx, &Call{Op: "GetPost", Args: []any{postID}}))
+}
+
+func (c *chainedAPI) GetPosts(ctx context.Context, postIDs []string) ([]*reddit.Post, error) {
+	return result[[]*reddit.Post](c.invoke(ctx, &Call{Op: "GetPosts", Args: []any{postIDs}}))
+}
+
+func (c *chainedAPI) DeletePost(ctx context.Context, postID string) error {
+	_, err := c.invoke(ctx, &Call{Op: "DeletePost", Args: []any{postID}})
+	return err
+}
+
+func (c *chainedAPI) SubmitComment(ctx context.Context, parentID, text string) (*reddit.Comment, error) {
+	return result[*reddit.Comment](c.invoke(ctx, &Call{Op: "SubmitComment", Args: []any{parentID, text}}))
+}
+
+func (c *chainedAPI) EditComment(ctx context.Context, commentID, text string) (*reddit.Comment, error) {
+	return result[*reddit.Comment](c.invoke(ctx, &Call{Op: "EditComment", Args: []any{commentID, text}}))
+}
+
+func (c *chainedAPI) DeleteComment(ctx context.Context, commentID string) error {
+	_, err := c.invoke(ctx, &Call{Op: "DeleteComment", Args: []any{commentID}})
+	return err
+}
+
+func (c *chainedAPI) ListNewPosts(ctx context.Context, subreddit string, opts *reddit.ListOptions) ([]*reddit.Post, error) {
+	return result[[]*reddit.Post](c.invoke(ctx, &Call{Op: "ListNewPosts", Args: []any{subreddit, opts}}))
+}
+
+func (c *chainedAPI) SearchPosts(ctx context.Context, subreddit, query string) ([]*reddit.Post, error) {
+	return result[[]*reddit.Post](c.invoke(ctx, &Call{Op: "SearchPosts", Args: []any{subreddit, query}}))
+}
+
+// readOnlyOps are the calls that are always safe to repeat.
+var readOnlyOps = map[string]bool{
+	"GetPost":      true,
+	"GetPosts":     true,
+	"ListNewPosts": true,
+	"SearchPosts":  true,
+}
+
+// Retry retries failed calls up to attempts times in total, doubling the
+// wait from backoff each time, or waiting for the rate limit to reset.
+// Reads are retried on rate limits and server errors; writes only when
+// Reddit rejected them outright, since a write that hit a server error
+// may have been applied.
+func Retry(attempts int, backoff time.Duration) Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (any, error) {
+			wait := backoff
+			for attempt := 1; ; attempt++ {
+				v, err := next(ctx, call)
+				if err == nil || attempt >= attempts || !retryable(call.Op, err) {
+					return v, err
+				}
+
+				delay := wait
+				var rateErr *reddit.RateLimitError
+				if errors.As(err, &rateErr) {
+					delay = max(delay, time.Until(rateErr.Rate.Reset))
+				}
+
+				select {
+				case <-ctx.Done():
+					return v, err
+				case <-time.After(delay):
+				}
+				wait *= 2
+			}
+		}
+	}
+}
+
+// retryable reports whether a failed call may be tried again.
+func retryable(op string, err error) bool {
+	var rateErr *reddit.RateLimitError
+	var errResp *reddit.ErrorResponse
+	var jsonErr *reddit.JSONErrorResponse
+	switch {
+	case errors.As(err, &rateErr):
+		// go-reddit also reports a rate limit after a request that
+		// succeeded but used up the quota, so only reads are safe
+		return readOnlyOps[op]
+	case errors.As(err, &jsonErr):
+		for _, apiErr := range jsonErr.JSON.Errors {
+			if apiErr.Label == "RATELIMIT" {
+				return true
+			}
+		}
+		return false
+	case errors.As(err, &errResp) && errResp.Response != nil:
+		status := errResp.Response.StatusCode
+		return status == http.StatusTooManyRequests || (status >= 500 && readOnlyOps[op])
+	}
+	return false
+}
+
+// Logging logs every call with its duration and error, if any.
+func Logging(logger *log.Logger) Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (any, error) {
+			start := time.Now()
+			v, err := next(ctx, call)
+			if err != nil {
+				logger.Printf("%s%v failed after %v: %v", call.Op, call.Args, time.Since(start), err)
+			} else {
+				logger.Printf("%s%v took %v", call.Op, call.Args, time.Since(start))
+			}
+			return v, err
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package redditkv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// failing returns a middleware that fails the first n calls to op.
+func failing(op string, n int, err error) (Middleware, *int) {
+	calls := 0
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (any, error) {
+			if call.Op != op {
+				return next(ctx, call)
+			}
+			calls++
+			if calls <= n {
+				return nil, err
+			}
+			return next(ctx, call)
+		}
+	}, &calls
+}
+
+func serverError(status int) error {
+	return &reddit.ErrorResponse{Response: &http.Response{
+		StatusCode: status,
+		Request:    &http.Request{Method: http.MethodGet},
+	}}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next Invoker) Invoker {
+			return func(ctx context.Context, call *Call) (any, error) {
+				order = append(order, name+">"+call.Op)
+				return next(ctx, call)
+			}
+		}
+	}
+
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit", WithMiddleware(tag("outer"), tag("inner")))
+	if _, err := client.Keys(); err != nil {
+		t.Fatalf("Keys failed: %v", err)
+	}
+
+	want := "outer>ListNewPosts inner>ListNewPosts"
+	if got := strings.Join(order, " "); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestMiddlewareResults(t *testing.T) {
+	var buf bytes.Buffer
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit", WithMiddleware(Logging(log.New(&buf, "", 0))))
+
+	_ = client.Set("mykey", "root")
+	_ = client.Append("mykey", "child", []int{0})
+	value, err := client.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "root" || len(value.Children) != 1 {
+		t.Errorf("Unexpected tree: %+v", value)
+	}
+
+	for _, op := range []string{"SearchPosts", "SubmitPost", "SubmitComment", "GetPost"} {
+		if !strings.Contains(buf.String(), op+"[") {
+			t.Errorf("Expected %s to be logged, got:\n%s", op, buf.String())
+		}
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	block := func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (any, error) {
+			return nil, errors.New("blocked")
+		}
+	}
+
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit", WithMiddleware(block))
+	if _, err := client.Get("mykey"); err == nil || !strings.Contains(err.Error(), "blocked") {
+		t.Errorf("Expected blocked error, got %v", err)
+	}
+}
+
+func TestMiddlewareWrongResultType(t *testing.T) {
+	wrong := func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (any, error) {
+			if call.Op == "GetPost" {
+				return "not a post", nil
+			}
+			return next(ctx, call)
+		}
+	}
+
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit", WithMiddleware(wrong))
+	if err := client.Set("mykey", "value"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := client.Get("mykey"); err == nil || !strings.Contains(err.Error(), "middleware returned string") {
+		t.Errorf("Expected wrong result type error, got %v", err)
+	}
+}
+
+func TestRetryReads(t *testing.T) {
+	fail, calls := failing("SearchPosts", 2, serverError(http.StatusServiceUnavailable))
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit", WithMiddleware(Retry(3, 0), fail))
+
+	if _, err := client.Get("mykey"); !errors.As(err, new(*KeyNotFoundError)) {
+		t.Errorf("Expected KeyNotFoundError after retries, got %v", err)
+	}
+	if *calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", *calls)
+	}
+}
+
+func TestRetryWrites(t *testing.T) {
+	mock := NewMockRedditAPI()
+
+	// A write that hit a server error may have been applied
+	fail, calls := failing("SubmitPost", 1, serverError(http.StatusInternalServerError))
+	client := NewWithAPI(mock, "testsubreddit", WithMiddleware(Retry(3, 0), fail))
+	if err := client.Set("mykey", "root"); err == nil {
+		t.Error("Expected Set to fail")
+	}
+	if *calls != 1 {
+		t.Errorf("Expected 1 attempt, got %d", *calls)
+	}
+
+	// A rate-limited write was rejected, so it's safe to repeat
+	fail, calls = failing("SubmitPost", 1, serverError(http.StatusTooManyRequests))
+	client = NewWithAPI(mock, "testsubreddit", WithMiddleware(Retry(3, 0), fail))
+	if err := client.Set("mykey", "root"); err != nil {
+		t.Errorf("Set failed: %v", err)
+	}
+	if *calls != 2 {
+		t.Errorf("Expected 2 attempts, got %d", *calls)
+	}
+}
@@ -3,7 +3,12 @@ package redditkv
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/vartanbeno/go-reddit/v2/reddit"
@@ -15,11 +20,18 @@ type MockRedditAPI struct {
 	posts     map[string]*mockPost       // postID -> post
 	comments  map[string]*reddit.Comment // commentID -> comment
 	idCounter int
+
+	callsMu sync.Mutex
+	calls   map[string]int // method name -> number of calls
+
+	latency atomic.Int64 // delay before each call, see SetLatency
 }
 
 type mockPost struct {
 	post     *reddit.Post
 	comments []*reddit.Comment // top-level comments
+	unsearch bool              // hidden from SearchPosts
+	archived bool              // rejects new comments and edits
 }
 
 // NewMockRedditAPI creates a new mock Reddit API for testing.
@@ -27,6 +39,18 @@ func NewMockRedditAPI() *MockRedditAPI {
 	return &MockRedditAPI{
 		posts:    make(map[string]*mockPost),
 		comments: make(map[string]*reddit.Comment),
+		calls:    make(map[string]int),
+	}
+}
+
+// called counts a call to a RedditAPI method.
+func (m *MockRedditAPI) called(op string) {
+	m.callsMu.Lock()
+	m.calls[op]++
+	m.callsMu.Unlock()
+
+	if d := time.Duration(m.latency.Load()); d > 0 {
+		time.Sleep(d)
 	}
 }
 
@@ -36,6 +60,7 @@ func (m *MockRedditAPI) nextID() string {
 }
 
 func (m *MockRedditAPI) SubmitPost(ctx context.Context, subreddit, title, text string) (*reddit.Submitted, error) {
+	m.called("SubmitPost")
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -65,6 +90,7 @@ func (m *MockRedditAPI) SubmitPost(ctx context.Context, subreddit, title, text s
 }
 
 func (m *MockRedditAPI) GetPost(ctx context.Context, postID string) (*reddit.PostAndComments, error) {
+	m.called("GetPost")
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -74,12 +100,27 @@ func (m *MockRedditAPI) GetPost(ctx context.Context, postID string) (*reddit.Pos
 	}
 
 	return &reddit.PostAndComments{
-		Post:     mp.post,
-		Comments: mp.comments,
+		Post:     copyPost(mp.post),
+		Comments: snapshotComments(mp.comments),
 	}, nil
 }
 
+func (m *MockRedditAPI) GetPosts(ctx context.Context, postIDs []string) ([]*reddit.Post, error) {
+	m.called("GetPosts")
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var posts []*reddit.Post
+	for _, id := range postIDs {
+		if mp, ok := m.posts[id]; ok {
+			posts = append(posts, copyPost(mp.post))
+		}
+	}
+	return posts, nil
+}
+
 func (m *MockRedditAPI) DeletePost(ctx context.Context, postID string) error {
+	m.called("DeletePost")
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -92,6 +133,10 @@ func (m *MockRedditAPI) DeletePost(ctx context.Context, postID string) error {
 }
 
 func (m *MockRedditAPI) SubmitComment(ctx context.Context, parentID, text string) (*reddit.Comment, error) {
+	m.called("SubmitComment")
+	if m.isArchived(parentID) {
+		return nil, tooOldError("/api/comment")
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -117,28 +162,113 @@ func (m *MockRedditAPI) SubmitComment(ctx context.Context, parentID, text string
 		if mp, ok := m.posts[postID]; ok {
 			mp.comments = append(mp.comments, comment)
 		}
+		comment.PostID = parentID
 	} else if len(parentID) > 3 && parentID[:3] == "t1_" {
 		// Parent is a comment
 		parentCommentID := parentID[3:]
 		if parentComment, ok := m.comments[parentCommentID]; ok {
 			parentComment.Replies.Comments = append(parentComment.Replies.Comments, comment)
+			comment.PostID = parentComment.PostID
+		}
+	}
+
+	// Keep the post's comment count in sync, as Reddit does
+	if len(comment.PostID) > 3 {
+		if mp, ok := m.posts[comment.PostID[3:]]; ok {
+			mp.post.NumberOfComments++
 		}
 	}
 
 	return comment, nil
 }
 
+func (m *MockRedditAPI) EditComment(ctx context.Context, commentID, text string) (*reddit.Comment, error) {
+	m.called("EditComment")
+	if m.isArchived(commentID) {
+		return nil, tooOldError("/api/editusertext")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	comment, ok := m.comments[strings.TrimPrefix(commentID, "t1_")]
+	if !ok {
+		return nil, fmt.Errorf("comment not found: %s", commentID)
+	}
+
+	now := reddit.Timestamp{Time: time.Now()}
+	comment.Body = text
+	comment.Edited = &now
+
+	return comment, nil
+}
+
+func (m *MockRedditAPI) DeleteComment(ctx context.Context, commentID string) error {
+	m.called("DeleteComment")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := strings.TrimPrefix(commentID, "t1_")
+	comment, ok := m.comments[id]
+	if !ok {
+		return fmt.Errorf("comment not found: %s", commentID)
+	}
+
+	// Like Reddit, a deleted comment with replies stays as a placeholder
+	// so the thread remains intact; otherwise it disappears.
+	if len(comment.Replies.Comments) > 0 {
+		comment.Body = "[deleted]"
+		comment.Author = "[deleted]"
+		return nil
+	}
+
+	delete(m.comments, id)
+	if strings.HasPrefix(comment.ParentID, "t3_") {
+		if mp, ok := m.posts[comment.ParentID[3:]]; ok {
+			mp.comments = removeComment(mp.comments, comment)
+		}
+	} else if parent, ok := m.comments[strings.TrimPrefix(comment.ParentID, "t1_")]; ok {
+		parent.Replies.Comments = removeComment(parent.Replies.Comments, comment)
+	}
+
+	return nil
+}
+
+// removeComment returns a copy of comments without target. It copies
+// rather than filtering in place so earlier GetPost results stay intact.
+func removeComment(comments []*reddit.Comment, target *reddit.Comment) []*reddit.Comment {
+	out := make([]*reddit.Comment, 0, len(comments))
+	for _, comment := range comments {
+		if comment != target {
+			out = append(out, comment)
+		}
+	}
+	return out
+}
+
 func (m *MockRedditAPI) ListNewPosts(ctx context.Context, subreddit string, opts *reddit.ListOptions) ([]*reddit.Post, error) {
+	m.called("ListNewPosts")
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	// Like Reddit's listings, After continues below the given post
+	var after int
+	if opts != nil && opts.After != "" {
+		after, _ = strconv.Atoi(strings.TrimPrefix(opts.After, "t3_"))
+	}
+
 	var posts []*reddit.Post
 	for _, mp := range m.posts {
-		if mp.post.SubredditName == subreddit {
-			posts = append(posts, mp.post)
+		if mp.post.SubredditName != subreddit {
+			continue
 		}
+		if id, _ := strconv.Atoi(mp.post.ID); after > 0 && id >= after {
+			continue
+		}
+		posts = append(posts, copyPost(mp.post))
 	}
 
+	sortNewestFirst(posts)
+
 	// Apply limit if specified
 	if opts != nil && opts.Limit > 0 && len(posts) > opts.Limit {
 		posts = posts[:opts.Limit]
@@ -148,19 +278,50 @@ func (m *MockRedditAPI) ListNewPosts(ctx context.Context, subreddit string, opts
 }
 
 func (m *MockRedditAPI) SearchPosts(ctx context.Context, subreddit, query string) ([]*reddit.Post, error) {
+	m.called("SearchPosts")
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	var posts []*reddit.Post
 	for _, mp := range m.posts {
-		if mp.post.SubredditName == subreddit && mp.post.Title == query {
-			posts = append(posts, mp.post)
+		if mp.post.SubredditName == subreddit && mp.post.Title == query && !mp.unsearch {
+			posts = append(posts, copyPost(mp.post))
 		}
 	}
 
+	sortNewestFirst(posts)
+
 	return posts, nil
 }
 
+// copyPost returns a copy of a post, so callers can read it while other
+// goroutines keep writing to the mock.
+func copyPost(post *reddit.Post) *reddit.Post {
+	cp := *post
+	return &cp
+}
+
+// snapshotComments deep-copies a comment tree, for the same reason.
+func snapshotComments(comments []*reddit.Comment) []*reddit.Comment {
+	out := make([]*reddit.Comment, len(comments))
+	for i, comment := range comments {
+		cp := *comment
+		cp.Replies.Comments = snapshotComments(comment.Replies.Comments)
+		out[i] = &cp
+	}
+	return out
+}
+
+// sortNewestFirst orders posts the way Reddit's "new" sort does.
+// Mock IDs are sequential, so they reflect creation order.
+func sortNewestFirst(posts []*reddit.Post) {
+	sort.Slice(posts, func(i, j int) bool {
+		a, _ := strconv.Atoi(posts[i].ID)
+		b, _ := strconv.Atoi(posts[j].ID)
+		return a > b
+	})
+}
+
 // Helper methods for testing
 
 // GetPostCount returns the number of posts in the mock.
@@ -177,6 +338,83 @@ func (m *MockRedditAPI) GetCommentCount() int {
 	return len(m.comments)
 }
 
+// CallCount returns how many times a RedditAPI method was called, e.g.
+// CallCount("SearchPosts").
+func (m *MockRedditAPI) CallCount(op string) int {
+	m.callsMu.Lock()
+	defer m.callsMu.Unlock()
+	return m.calls[op]
+}
+
+// SetPostArchived marks a post as archived, so that Reddit rejects new
+// comments on it and edits to its comments with TOO_OLD.
+func (m *MockRedditAPI) SetPostArchived(postID string, archived bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mp, ok := m.posts[postID]; ok {
+		mp.archived = archived
+	}
+}
+
+// isArchived reports whether the post a thing belongs to is archived.
+func (m *MockRedditAPI) isArchived(fullID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if strings.HasPrefix(fullID, "t1_") {
+		comment, ok := m.comments[fullID[3:]]
+		if !ok {
+			return false
+		}
+		fullID = comment.PostID
+	}
+	mp, ok := m.posts[strings.TrimPrefix(fullID, "t3_")]
+	return ok && mp.archived
+}
+
+// tooOldError is the error Reddit returns for writes to archived posts.
+func tooOldError(endpoint string) error {
+	req, _ := http.NewRequest(http.MethodPost, "https://oauth.reddit.com"+endpoint, nil)
+	err := &reddit.JSONErrorResponse{Response: &http.Response{StatusCode: http.StatusOK, Request: req}}
+	err.JSON.Errors = []reddit.APIError{{Label: "TOO_OLD", Reason: "that's a piece of history now", Field: "parent"}}
+	return err
+}
+
+// SetPostLocked marks a post as locked or unlocked by a moderator.
+func (m *MockRedditAPI) SetPostLocked(postID string, locked bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mp, ok := m.posts[postID]; ok {
+		mp.post.Locked = locked
+	}
+}
+
+// HideFromSearch simulates Reddit's search index missing a post.
+func (m *MockRedditAPI) HideFromSearch(postID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mp, ok := m.posts[postID]; ok {
+		mp.unsearch = true
+	}
+}
+
+// SetLatency makes every call wait d before touching the mock, like a
+// round trip to Reddit. Combined with many goroutines sharing one mock,
+// it widens the windows in which competing clients interleave.
+func (m *MockRedditAPI) SetLatency(d time.Duration) {
+	m.latency.Store(int64(d))
+}
+
+// RemoveComment simulates a moderator removing a comment.
+func (m *MockRedditAPI) RemoveComment(commentID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if comment, ok := m.comments[commentID]; ok {
+		comment.Body = "[removed]"
+		comment.Author = "[deleted]"
+	}
+}
+
 // Reset clears all data in the mock.
 func (m *MockRedditAPI) Reset() {
 	m.mu.Lock()
@@ -184,4 +422,8 @@ func (m *MockRedditAPI) Reset() {
 	m.posts = make(map[string]*mockPost)
 	m.comments = make(map[string]*reddit.Comment)
 	m.idCounter = 0
+
+	m.callsMu.Lock()
+	clear(m.calls)
+	m.callsMu.Unlock()
 }
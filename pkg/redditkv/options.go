@@ -0,0 +1,26 @@
+package redditkv
+
+import "time"
+
+// defaultSettleDelay is how long New waits for Reddit's search index to
+// catch up before re-checking a conditional write for competing posts.
+const defaultSettleDelay = 2 * time.Second
+
+// Option configures optional KVClient behavior.
+type Option func(*KVClient)
+
+// WithAutoMigrate makes Append repost an archived key's value tree to a
+// fresh post before appending, instead of returning an ArchivedKeyError.
+func WithAutoMigrate() Option {
+	return func(c *KVClient) {
+		c.autoMigrate = true
+	}
+}
+
+// WithSettleDelay sets how long conditional writes such as SetIfAbsent wait
+// before re-checking for posts created concurrently by other writers.
+func WithSettleDelay(d time.Duration) Option {
+	return func(c *KVClient) {
+		c.settleDelay = d
+	}
+}
@@ -0,0 +1,26 @@
+package redditkv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParsePath parses a comma-separated node path such as "0,1" (the second
+// child of the first child). An empty string is the nil path.
+func ParsePath(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, ",")
+	path := make([]int, len(parts))
+	for i, part := range parts {
+		idx, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid path: %s", s)
+		}
+		path[i] = idx
+	}
+	return path, nil
+}
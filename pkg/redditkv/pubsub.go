@@ -0,0 +1,180 @@
+package redditkv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// channelKeyPrefix namespaces channel posts so they don't collide with
+// user keys.
+const channelKeyPrefix = "_chan:"
+
+// Message is one message published on a channel.
+//
+// Each channel is a post and each message a top-level comment on it.
+// Comment IDs only ever grow, so a message's ID orders it on its channel
+// and lets subscribers pick up where they left off.
+type Message struct {
+	// ID is the message comment's ID; pass it to SubscribeAfter to
+	// resume a subscription.
+	ID        string    `json:"id"`
+	Channel   string    `json:"channel"`
+	Body      string    `json:"body"`
+	Published time.Time `json:"published"`
+}
+
+// messageBody is the JSON body of a message comment.
+type messageBody struct {
+	Body      string    `json:"body"`
+	Published time.Time `json:"published"`
+}
+
+// Publish sends a message on the named channel, creating the channel if
+// it doesn't exist yet.
+func (c *KVClient) Publish(channel, msg string) (*Message, error) {
+	post, err := c.channelPost(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(messageBody{Body: msg, Published: time.Now().UTC()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	comment, err := c.api.SubmitComment(c.ctx, post.FullID, string(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish: %w", err)
+	}
+	return messageFrom(channel, comment)
+}
+
+// Subscribe polls the named channel and sends each message published
+// after the call until ctx is done, then closes the channel. The channel
+// is created if it doesn't exist yet.
+func (c *KVClient) Subscribe(ctx context.Context, channel string) (<-chan Message, error) {
+	post, err := c.channelPost(channel)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := c.channelMessages(channel, post.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var last string
+	if len(messages) > 0 {
+		last = messages[len(messages)-1].ID
+	}
+	return c.subscribe(ctx, channel, post.ID, last), nil
+}
+
+// SubscribeAfter is like Subscribe but starts with the messages published
+// after the one with the given ID, so a subscriber can resume without
+// missing or repeating messages. An empty ID starts from the oldest
+// message still on the channel.
+func (c *KVClient) SubscribeAfter(ctx context.Context, channel, id string) (<-chan Message, error) {
+	post, err := c.channelPost(channel)
+	if err != nil {
+		return nil, err
+	}
+	return c.subscribe(ctx, channel, post.ID, id), nil
+}
+
+// subscribe polls a channel post, sending messages newer than last. Polls
+// that fail are retried at the next interval. If the channel post is
+// replaced, the subscription follows the new one; comment IDs keep
+// growing across posts, so last still marks what was delivered.
+func (c *KVClient) subscribe(ctx context.Context, channel, postID, last string) <-chan Message {
+	out := make(chan Message)
+	go func() {
+		defer close(out)
+
+		deliver := func() bool {
+			messages, err := c.channelMessages(channel, postID)
+			if err != nil {
+				post, ferr := c.findPostByTitle(channelKeyPrefix + channel)
+				if ferr != nil || post == nil || post.ID == postID {
+					return true
+				}
+				postID = post.ID
+				if messages, err = c.channelMessages(channel, postID); err != nil {
+					return true
+				}
+			}
+
+			for _, msg := range messages {
+				if last != "" && !idLess(last, msg.ID) {
+					continue
+				}
+				select {
+				case out <- *msg:
+				case <-ctx.Done():
+					return false
+				}
+				last = msg.ID
+			}
+			return true
+		}
+
+		// Anything already past the starting point goes out right away
+		if !deliver() {
+			return
+		}
+		c.poll(ctx, deliver)
+	}()
+	return out
+}
+
+// channelPost finds or creates the post backing a channel.
+func (c *KVClient) channelPost(channel string) (*reddit.Post, error) {
+	return c.bookkeepingPost(channelKeyPrefix+channel, "channel")
+}
+
+// channelMessages returns the messages on a channel post, oldest first.
+// Comments that aren't messages are skipped.
+func (c *KVClient) channelMessages(channel, postID string) ([]*Message, error) {
+	postAndComments, err := c.api.GetPost(c.ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel: %w", err)
+	}
+	// Reddit keeps serving deleted posts, with their body blanked
+	if isRemovedBody(postAndComments.Post.Body) {
+		return nil, fmt.Errorf("channel was deleted: %s", channel)
+	}
+
+	var messages []*Message
+	for _, comment := range postAndComments.Comments {
+		msg, err := messageFrom(channel, comment)
+		if err != nil {
+			continue
+		}
+		messages = append(messages, msg)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return idLess(messages[i].ID, messages[j].ID)
+	})
+	return messages, nil
+}
+
+func messageFrom(channel string, comment *reddit.Comment) (*Message, error) {
+	var body messageBody
+	if err := json.Unmarshal([]byte(comment.Body), &body); err != nil {
+		return nil, fmt.Errorf("not a message: %w", err)
+	}
+	if body.Published.IsZero() {
+		return nil, fmt.Errorf("not a message: %s", comment.ID)
+	}
+	return &Message{
+		ID:        comment.ID,
+		Channel:   channel,
+		Body:      body.Body,
+		Published: body.Published,
+	}, nil
+}
@@ -0,0 +1,77 @@
+package redditkv
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// nextMessage waits for the next message from a subscription.
+func nextMessage(t *testing.T, messages <-chan Message) Message {
+	t.Helper()
+	select {
+	case msg, ok := <-messages:
+		if !ok {
+			t.Fatal("Expected a message, channel was closed")
+		}
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for a message")
+	}
+	return Message{}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit", WithPollInterval(5*time.Millisecond))
+	publisher := NewWithAPI(mock, "testsubreddit")
+
+	if _, err := publisher.Publish("news", "before"); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	messages, err := client.Subscribe(ctx, "news")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	first, _ := publisher.Publish("news", "one")
+	_, _ = publisher.Publish("news", "two")
+	_, _ = publisher.Publish("other", "elsewhere")
+
+	// Messages published before subscribing aren't delivered
+	for _, want := range []string{"one", "two"} {
+		if msg := nextMessage(t, messages); msg.Body != want || msg.Channel != "news" {
+			t.Errorf("Expected '%s' on news, got %+v", want, msg)
+		}
+	}
+
+	// Later polls don't repeat delivered messages
+	time.Sleep(20 * time.Millisecond)
+	_, _ = publisher.Publish("news", "three")
+	if msg := nextMessage(t, messages); msg.Body != "three" {
+		t.Errorf("Expected 'three', got %+v", msg)
+	}
+
+	// Resuming after a message picks up everything since
+	resumed, err := client.SubscribeAfter(ctx, "news", first.ID)
+	if err != nil {
+		t.Fatalf("SubscribeAfter failed: %v", err)
+	}
+	for _, want := range []string{"two", "three"} {
+		if msg := nextMessage(t, resumed); msg.Body != want {
+			t.Errorf("Expected '%s', got %+v", want, msg)
+		}
+	}
+
+	keys, _ := client.Keys()
+	if len(keys) != 0 {
+		t.Errorf("Expected channels to be hidden from Keys, got %v", keys)
+	}
+
+	cancel()
+	for range messages {
+	}
+}
@@ -0,0 +1,394 @@
+package redditkv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// queueKeyPrefix namespaces queue posts so they don't collide with user
+// keys.
+const queueKeyPrefix = "_queue:"
+
+// defaultDeadLetterThreshold is how many times a job can be claimed
+// before Claim moves it to the dead-letter queue, unless
+// WithDeadLetterThreshold says otherwise.
+const defaultDeadLetterThreshold = 5
+
+// WithDeadLetterThreshold sets how many times a job can be claimed
+// without being acknowledged before Claim moves it to its queue's
+// dead-letter queue.
+func WithDeadLetterThreshold(n int) Option {
+	return func(c *KVClient) {
+		c.deadLetterThreshold = n
+	}
+}
+
+// DeadLetterQueue returns the name of the queue that jobs from queue are
+// moved to once they reach the dead-letter threshold. It is an ordinary
+// queue, so its jobs can be inspected and claimed like any other.
+func DeadLetterQueue(queue string) string {
+	return queue + ":dead"
+}
+
+// Job is a job claimed from a queue.
+//
+// Each queue is a key whose top-level comments are its jobs. Claiming a
+// job replies to its comment with the worker and an expiry; the earliest
+// unexpired claim holds the job, and until it expires other workers skip
+// it. Acknowledging a job deletes its comment. Every claim that isn't
+// acknowledged stays behind as a reply, so the replies also count the
+// job's attempts.
+type Job struct {
+	// ID is the job comment's ID.
+	ID       string
+	Queue    string
+	Payload  string
+	Enqueued time.Time
+
+	// Attempt counts the claims on the job so far, this one included.
+	Attempt int
+	Worker  string
+	Expires time.Time
+
+	client  *KVClient
+	postID  string
+	claimID string // full ID of the claim reply; empty if not claimed
+}
+
+// QueueStats describes a queue's contents.
+type QueueStats struct {
+	// Ready counts jobs that can be claimed.
+	Ready int
+	// InFlight counts jobs claimed by a worker whose claim hasn't expired.
+	InFlight int
+	// Oldest is the oldest job still in the queue, ready or in flight;
+	// nil if the queue is empty. It is for inspection only.
+	Oldest *Job
+}
+
+// jobBody is the JSON body of a job comment.
+type jobBody struct {
+	Payload  string    `json:"payload"`
+	Enqueued time.Time `json:"enqueued"`
+}
+
+// jobClaim is the JSON body of a claim reply.
+type jobClaim struct {
+	Worker  string    `json:"worker"`
+	Expires time.Time `json:"expires"`
+
+	comment *reddit.Comment
+}
+
+// queuedJob is a job comment with its claims in creation order.
+type queuedJob struct {
+	comment *reddit.Comment
+	body    jobBody
+	claims  []*jobClaim
+}
+
+// holder returns the claim holding the job at now, or nil if the job is
+// ready to be claimed.
+func (j *queuedJob) holder(now time.Time) *jobClaim {
+	for _, claim := range j.claims {
+		if claim.Expires.After(now) {
+			return claim
+		}
+	}
+	return nil
+}
+
+// Enqueue appends a job to the named queue, creating the queue if it
+// doesn't exist yet.
+func (c *KVClient) Enqueue(queue, payload string) error {
+	body, err := json.Marshal(jobBody{Payload: payload, Enqueued: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	key := queueKeyPrefix + queue
+	err = c.Append(key, string(body), nil)
+	var notFound *KeyNotFoundError
+	if errors.As(err, &notFound) {
+		if _, err := c.bookkeepingPost(key, "queue"); err != nil {
+			return err
+		}
+		err = c.Append(key, string(body), nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to enqueue: %w", err)
+	}
+	return nil
+}
+
+// Claim takes the oldest ready job from the named queue for
+// visibilityTimeout. Until it is acknowledged or the claim expires, other
+// workers don't see the job; after that it is claimed again. A job
+// claimed more times than the dead-letter threshold is moved to the
+// dead-letter queue instead of being returned. If no job is ready,
+// ErrQueueEmpty is returned. An empty worker identifies this process.
+func (c *KVClient) Claim(queue, worker string, visibilityTimeout time.Duration) (*Job, error) {
+	if worker == "" {
+		worker = c.holderID()
+	}
+
+	post, err := c.findPostByTitle(queueKeyPrefix + queue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find queue: %w", err)
+	}
+	if post == nil {
+		return nil, ErrQueueEmpty
+	}
+
+	jobs, err := c.queueJobs(post.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := c.deadLetterThreshold
+	if threshold <= 0 {
+		threshold = defaultDeadLetterThreshold
+	}
+
+	for _, qj := range jobs {
+		if qj.holder(time.Now()) != nil {
+			continue
+		}
+
+		job, err := c.claimJob(queue, post.ID, qj, worker, visibilityTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if job == nil {
+			// Another worker got there first
+			continue
+		}
+
+		if job.Attempt > threshold {
+			if err := job.deadLetter(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return job, nil
+	}
+
+	return nil, ErrQueueEmpty
+}
+
+// claimJob posts a claim on a job and checks whether it won. It returns
+// nil if another worker's claim won or the job is gone.
+func (c *KVClient) claimJob(queue, postID string, qj *queuedJob, worker string, timeout time.Duration) (*Job, error) {
+	claim := jobClaim{Worker: worker, Expires: time.Now().Add(timeout)}
+	body, err := json.Marshal(claim)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode claim: %w", err)
+	}
+
+	ours, err := c.api.SubmitComment(c.ctx, qj.comment.FullID, string(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	// Competing claims were submitted around the same time; the earliest
+	// one still unexpired wins, so everyone agrees once they re-read.
+	fresh, err := c.queueJob(postID, qj.comment.ID)
+	if err != nil {
+		_ = c.api.DeleteComment(c.ctx, ours.FullID)
+		return nil, err
+	}
+	if fresh == nil {
+		_ = c.api.DeleteComment(c.ctx, ours.FullID)
+		return nil, nil
+	}
+	winner := fresh.holder(time.Now())
+	if winner == nil || winner.comment.ID != ours.ID {
+		_ = c.api.DeleteComment(c.ctx, ours.FullID)
+		return nil, nil
+	}
+
+	job := c.jobFrom(queue, postID, fresh)
+	job.Worker = worker
+	job.Expires = claim.Expires
+	job.claimID = ours.FullID
+	job.Attempt = 0
+	for _, other := range fresh.claims {
+		if !idLess(ours.ID, other.comment.ID) {
+			job.Attempt++
+		}
+	}
+	return job, nil
+}
+
+// Ack acknowledges the job, removing it from its queue. It fails with a
+// ClaimLostError if the claim expired and another worker took the job.
+func (j *Job) Ack() error {
+	qj, err := j.current()
+	if err != nil {
+		return err
+	}
+	return j.client.removeJob(qj)
+}
+
+// Nack gives up the claim, so the job can be claimed again right away.
+// The claim still counts towards the dead-letter threshold.
+func (j *Job) Nack() error {
+	c := j.client
+	if _, err := j.current(); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(jobClaim{Worker: j.Worker, Expires: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to encode claim: %w", err)
+	}
+	if _, err := c.api.EditComment(c.ctx, j.claimID, string(body)); err != nil {
+		return fmt.Errorf("failed to release job: %w", err)
+	}
+	j.Expires = time.Time{}
+	return nil
+}
+
+// current re-reads the job and checks that this claim still holds it.
+func (j *Job) current() (*queuedJob, error) {
+	qj, err := j.client.queueJob(j.postID, j.ID)
+	if err != nil {
+		return nil, err
+	}
+	if qj == nil {
+		return nil, &ClaimLostError{Queue: j.Queue, JobID: j.ID}
+	}
+	if holder := qj.holder(time.Now()); holder != nil && holder.comment.FullID != j.claimID {
+		return nil, &ClaimLostError{Queue: j.Queue, JobID: j.ID}
+	}
+	return qj, nil
+}
+
+// deadLetter moves a claimed job to its dead-letter queue.
+func (j *Job) deadLetter() error {
+	c := j.client
+	if err := c.Enqueue(DeadLetterQueue(j.Queue), j.Payload); err != nil {
+		return fmt.Errorf("failed to dead-letter job: %w", err)
+	}
+	qj, err := c.queueJob(j.postID, j.ID)
+	if err != nil || qj == nil {
+		return err
+	}
+	return c.removeJob(qj)
+}
+
+// removeJob deletes a job's comment, then its claims. The job comment
+// goes first so that no worker can win a claim on it in between.
+func (c *KVClient) removeJob(qj *queuedJob) error {
+	if err := c.api.DeleteComment(c.ctx, qj.comment.FullID); err != nil {
+		return fmt.Errorf("failed to remove job: %w", err)
+	}
+	// The job is already gone; leftover claims are just clutter
+	for _, claim := range qj.claims {
+		_ = c.api.DeleteComment(c.ctx, claim.comment.FullID)
+	}
+	return nil
+}
+
+// QueueStats counts the jobs in the named queue. A queue that doesn't
+// exist is empty.
+func (c *KVClient) QueueStats(queue string) (*QueueStats, error) {
+	post, err := c.findPostByTitle(queueKeyPrefix + queue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find queue: %w", err)
+	}
+	if post == nil {
+		return &QueueStats{}, nil
+	}
+
+	jobs, err := c.queueJobs(post.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &QueueStats{}
+	now := time.Now()
+	for _, qj := range jobs {
+		if qj.holder(now) != nil {
+			stats.InFlight++
+		} else {
+			stats.Ready++
+		}
+	}
+	if len(jobs) > 0 {
+		stats.Oldest = c.jobFrom(queue, post.ID, jobs[0])
+	}
+	return stats, nil
+}
+
+// jobFrom describes a queued job. Its claim fields reflect whoever holds
+// it, if anyone.
+func (c *KVClient) jobFrom(queue, postID string, qj *queuedJob) *Job {
+	job := &Job{
+		ID:       qj.comment.ID,
+		Queue:    queue,
+		Payload:  qj.body.Payload,
+		Enqueued: qj.body.Enqueued,
+		Attempt:  len(qj.claims),
+		client:   c,
+		postID:   postID,
+	}
+	if holder := qj.holder(time.Now()); holder != nil {
+		job.Worker = holder.Worker
+		job.Expires = holder.Expires
+	}
+	return job
+}
+
+// queueJob returns one job from a queue post, or nil if it is gone.
+func (c *KVClient) queueJob(postID, jobID string) (*queuedJob, error) {
+	jobs, err := c.queueJobs(postID)
+	if err != nil {
+		return nil, err
+	}
+	for _, qj := range jobs {
+		if qj.comment.ID == jobID {
+			return qj, nil
+		}
+	}
+	return nil, nil
+}
+
+// queueJobs returns the jobs on a queue post in creation order. Comments
+// that aren't jobs, such as deleted ones, are skipped.
+func (c *KVClient) queueJobs(postID string) ([]*queuedJob, error) {
+	postAndComments, err := c.api.GetPost(c.ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue: %w", err)
+	}
+
+	var jobs []*queuedJob
+	for _, comment := range postAndComments.Comments {
+		qj := &queuedJob{comment: comment}
+		if err := json.Unmarshal([]byte(comment.Body), &qj.body); err != nil || qj.body.Enqueued.IsZero() {
+			continue
+		}
+		for _, reply := range comment.Replies.Comments {
+			var claim jobClaim
+			if err := json.Unmarshal([]byte(reply.Body), &claim); err != nil || claim.Worker == "" {
+				continue
+			}
+			claim.comment = reply
+			qj.claims = append(qj.claims, &claim)
+		}
+		sort.Slice(qj.claims, func(i, k int) bool {
+			return idLess(qj.claims[i].comment.ID, qj.claims[k].comment.ID)
+		})
+		jobs = append(jobs, qj)
+	}
+
+	sort.Slice(jobs, func(i, k int) bool {
+		return idLess(jobs[i].comment.ID, jobs[k].comment.ID)
+	})
+	return jobs, nil
+}
@@ -0,0 +1,186 @@
+package redditkv
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueueClaimAck(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit")
+
+	if _, err := client.Claim("jobs", "w1", time.Minute); !errors.Is(err, ErrQueueEmpty) {
+		t.Errorf("Expected ErrQueueEmpty for a missing queue, got %v", err)
+	}
+
+	for _, payload := range []string{"first", "second"} {
+		if err := client.Enqueue("jobs", payload); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	job, err := client.Claim("jobs", "w1", time.Minute)
+	if err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	if job.Payload != "first" || job.Attempt != 1 || job.Worker != "w1" {
+		t.Errorf("Unexpected job: %+v", job)
+	}
+
+	// The claimed job is invisible to other workers
+	other, err := client.Claim("jobs", "w2", time.Minute)
+	if err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	if other.Payload != "second" {
+		t.Errorf("Expected 'second', got '%s'", other.Payload)
+	}
+
+	stats, err := client.QueueStats("jobs")
+	if err != nil {
+		t.Fatalf("QueueStats failed: %v", err)
+	}
+	if stats.Ready != 0 || stats.InFlight != 2 || stats.Oldest.Payload != "first" {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	if err := job.Ack(); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+	if err := other.Nack(); err != nil {
+		t.Fatalf("Nack failed: %v", err)
+	}
+
+	stats, _ = client.QueueStats("jobs")
+	if stats.Ready != 1 || stats.InFlight != 0 || stats.Oldest.Payload != "second" {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// A nacked job comes back with its attempts counted
+	again, err := client.Claim("jobs", "w1", time.Minute)
+	if err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	if again.Payload != "second" || again.Attempt != 2 {
+		t.Errorf("Expected the second attempt at 'second', got %+v", again)
+	}
+
+	keys, _ := client.Keys()
+	if len(keys) != 0 {
+		t.Errorf("Expected queues to be hidden from Keys, got %v", keys)
+	}
+}
+
+func TestQueueVisibilityTimeout(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit")
+	_ = client.Enqueue("jobs", "slow")
+
+	stale, err := client.Claim("jobs", "w1", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	job, err := client.Claim("jobs", "w2", time.Minute)
+	if err != nil {
+		t.Fatalf("Expected the expired claim to be visible again: %v", err)
+	}
+	if job.ID != stale.ID || job.Attempt != 2 {
+		t.Errorf("Expected the second attempt at the same job, got %+v", job)
+	}
+
+	var lost *ClaimLostError
+	if err := stale.Ack(); !errors.As(err, &lost) {
+		t.Errorf("Expected ClaimLostError, got %v", err)
+	}
+	if err := job.Ack(); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+}
+
+func TestQueueDeadLetter(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit", WithDeadLetterThreshold(2))
+	_ = client.Enqueue("jobs", "poison")
+
+	for i := 0; i < 2; i++ {
+		job, err := client.Claim("jobs", "w1", time.Minute)
+		if err != nil {
+			t.Fatalf("Claim failed: %v", err)
+		}
+		_ = job.Nack()
+	}
+
+	if _, err := client.Claim("jobs", "w1", time.Minute); !errors.Is(err, ErrQueueEmpty) {
+		t.Errorf("Expected the job to be dead-lettered, got %v", err)
+	}
+
+	dead, err := client.Claim(DeadLetterQueue("jobs"), "w1", time.Minute)
+	if err != nil {
+		t.Fatalf("Claim on the dead-letter queue failed: %v", err)
+	}
+	if dead.Payload != "poison" {
+		t.Errorf("Expected 'poison', got '%s'", dead.Payload)
+	}
+
+	stats, _ := client.QueueStats("jobs")
+	if stats.Ready != 0 || stats.InFlight != 0 || stats.Oldest != nil {
+		t.Errorf("Expected an empty queue, got %+v", stats)
+	}
+}
+
+func TestQueueCompetingWorkers(t *testing.T) {
+	mock := NewMockRedditAPI()
+	producer := NewWithAPI(mock, "testsubreddit")
+	const numJobs = 20
+	for i := 0; i < numJobs; i++ {
+		if err := producer.Enqueue("jobs", fmt.Sprintf("job-%d", i)); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	// Slow calls let workers' claims interleave
+	mock.SetLatency(time.Millisecond)
+
+	var (
+		mu   sync.Mutex
+		done = make(map[string]string) // payload -> worker
+		wg   sync.WaitGroup
+	)
+	for w := 0; w < 8; w++ {
+		worker := fmt.Sprintf("w%d", w)
+		client := NewWithAPI(mock, "testsubreddit")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, err := client.Claim("jobs", worker, time.Minute)
+				if errors.Is(err, ErrQueueEmpty) {
+					return
+				}
+				if err != nil {
+					t.Errorf("Claim failed: %v", err)
+					return
+				}
+
+				mu.Lock()
+				if prev, ok := done[job.Payload]; ok {
+					t.Errorf("%s was processed by both %s and %s", job.Payload, prev, worker)
+				}
+				done[job.Payload] = worker
+				mu.Unlock()
+
+				if err := job.Ack(); err != nil {
+					t.Errorf("Ack failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(done) != numJobs {
+		t.Errorf("Expected %d jobs to be processed, got %d", numJobs, len(done))
+	}
+}
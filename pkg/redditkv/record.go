@@ -0,0 +1,398 @@
+package redditkv
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// interaction is one recorded RedditAPI call, stored as a line of JSON
+// in a cassette file. Results use Reddit's wire format so go-reddit's
+// own decoders read them back.
+type interaction struct {
+	Op     string          `json:"op"`
+	Args   json.RawMessage `json:"args"`
+	Result json.RawMessage `json:"result,omitempty"`
+
+	// Error is the call's error message. Reddit API errors also keep
+	// enough detail to be rebuilt as the same go-reddit error types.
+	Error     string     `json:"error,omitempty"`
+	Status    int        `json:"status,omitempty"`
+	Message   string     `json:"message,omitempty"`
+	Method    string     `json:"method,omitempty"`
+	URL       string     `json:"url,omitempty"`
+	APIErrors [][]string `json:"api_errors,omitempty"`
+}
+
+// RecordingRedditAPI wraps a RedditAPI and appends every call, with its
+// arguments and result, to a cassette file for ReplayRedditAPI.
+type RecordingRedditAPI struct {
+	api RedditAPI
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewRecordingRedditAPI records calls to api in a new cassette at path,
+// replacing any existing file.
+func NewRecordingRedditAPI(api RedditAPI, path string) (*RecordingRedditAPI, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cassette: %w", err)
+	}
+	return &RecordingRedditAPI{api: api, file: file}, nil
+}
+
+// Close closes the cassette file.
+func (r *RecordingRedditAPI) Close() error {
+	return r.file.Close()
+}
+
+// record writes one interaction. result is only encoded if err is nil.
+// Failing to record is reported as an error, since a silently incomplete
+// cassette would be useless for reproducing a bug.
+func (r *RecordingRedditAPI) record(op string, args []any, result any, err error) error {
+	in := interaction{Op: op}
+
+	var encodeErr error
+	in.Args, encodeErr = json.Marshal(args)
+	if encodeErr == nil && err == nil && result != nil {
+		in.Result, encodeErr = json.Marshal(result)
+	}
+	if encodeErr != nil {
+		return fmt.Errorf("failed to record %s: %w", op, encodeErr)
+	}
+
+	if err != nil {
+		in.Error = err.Error()
+		var jsonErr *reddit.JSONErrorResponse
+		var errResp *reddit.ErrorResponse
+		switch {
+		case errors.As(err, &jsonErr):
+			in.setResponse(jsonErr.Response)
+			for _, apiErr := range jsonErr.JSON.Errors {
+				in.APIErrors = append(in.APIErrors, []string{apiErr.Label, apiErr.Reason, apiErr.Field})
+			}
+		case errors.As(err, &errResp):
+			in.setResponse(errResp.Response)
+			in.Message = errResp.Message
+		}
+	}
+
+	line, encodeErr := json.Marshal(in)
+	if encodeErr != nil {
+		return fmt.Errorf("failed to record %s: %w", op, encodeErr)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, writeErr := r.file.Write(append(line, '\n')); writeErr != nil {
+		return fmt.Errorf("failed to record %s: %w", op, writeErr)
+	}
+	return nil
+}
+
+func (in *interaction) setResponse(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	in.Status = resp.StatusCode
+	if resp.Request != nil {
+		in.Method = resp.Request.Method
+		in.URL = resp.Request.URL.String()
+	}
+}
+
+func (r *RecordingRedditAPI) SubmitPost(ctx context.Context, subreddit, title, text string) (*reddit.Submitted, error) {
+	submitted, err := r.api.SubmitPost(ctx, subreddit, title, text)
+	if recErr := r.record("SubmitPost", []any{subreddit, title, text}, submitted, err); recErr != nil {
+		return nil, recErr
+	}
+	return submitted, err
+}
+
+func (r *RecordingRedditAPI) GetPost(ctx context.Context, postID string) (*reddit.PostAndComments, error) {
+	post, err := r.api.GetPost(ctx, postID)
+	var result any
+	if err == nil {
+		result = wirePostAndComments(post)
+	}
+	if recErr := r.record("GetPost", []any{postID}, result, err); recErr != nil {
+		return nil, recErr
+	}
+	return post, err
+}
+
+func (r *RecordingRedditAPI) GetPosts(ctx context.Context, postIDs []string) ([]*reddit.Post, error) {
+	posts, err := r.api.GetPosts(ctx, postIDs)
+	var result any
+	if err == nil {
+		result = wirePostListing(posts)
+	}
+	if recErr := r.record("GetPosts", []any{postIDs}, result, err); recErr != nil {
+		return nil, recErr
+	}
+	return posts, err
+}
+
+func (r *RecordingRedditAPI) DeletePost(ctx context.Context, postID string) error {
+	err := r.api.DeletePost(ctx, postID)
+	if recErr := r.record("DeletePost", []any{postID}, nil, err); recErr != nil {
+		return recErr
+	}
+	return err
+}
+
+func (r *RecordingRedditAPI) SubmitComment(ctx context.Context, parentID, text string) (*reddit.Comment, error) {
+	comment, err := r.api.SubmitComment(ctx, parentID, text)
+	var result any
+	if err == nil {
+		result = wireComment(comment)
+	}
+	if recErr := r.record("SubmitComment", []any{parentID, text}, result, err); recErr != nil {
+		return nil, recErr
+	}
+	return comment, err
+}
+
+func (r *RecordingRedditAPI) EditComment(ctx context.Context, commentID, text string) (*reddit.Comment, error) {
+	comment, err := r.api.EditComment(ctx, commentID, text)
+	var result any
+	if err == nil {
+		result = wireComment(comment)
+	}
+	if recErr := r.record("EditComment", []any{commentID, text}, result, err); recErr != nil {
+		return nil, recErr
+	}
+	return comment, err
+}
+
+func (r *RecordingRedditAPI) DeleteComment(ctx context.Context, commentID string) error {
+	err := r.api.DeleteComment(ctx, commentID)
+	if recErr := r.record("DeleteComment", []any{commentID}, nil, err); recErr != nil {
+		return recErr
+	}
+	return err
+}
+
+func (r *RecordingRedditAPI) ListNewPosts(ctx context.Context, subreddit string, opts *reddit.ListOptions) ([]*reddit.Post, error) {
+	posts, err := r.api.ListNewPosts(ctx, subreddit, opts)
+	var result any
+	if err == nil {
+		result = wirePostListing(posts)
+	}
+	if recErr := r.record("ListNewPosts", []any{subreddit, opts}, result, err); recErr != nil {
+		return nil, recErr
+	}
+	return posts, err
+}
+
+func (r *RecordingRedditAPI) SearchPosts(ctx context.Context, subreddit, query string) ([]*reddit.Post, error) {
+	posts, err := r.api.SearchPosts(ctx, subreddit, query)
+	var result any
+	if err == nil {
+		result = wirePostListing(posts)
+	}
+	if recErr := r.record("SearchPosts", []any{subreddit, query}, result, err); recErr != nil {
+		return nil, recErr
+	}
+	return posts, err
+}
+
+// ReplayRedditAPI serves the calls recorded in a cassette. Each call is
+// answered by the first not yet replayed interaction with the same
+// operation and arguments, so a replay is deterministic even if
+// independent calls happen in a different order than when recording.
+type ReplayRedditAPI struct {
+	mu           sync.Mutex
+	interactions []interaction
+	played       []bool
+}
+
+// NewReplayRedditAPI loads a cassette written by RecordingRedditAPI.
+func NewReplayRedditAPI(path string) (*ReplayRedditAPI, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cassette: %w", err)
+	}
+	defer file.Close()
+
+	r := &ReplayRedditAPI{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var in interaction
+		if err := json.Unmarshal(scanner.Bytes(), &in); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette line %d: %w", line, err)
+		}
+		r.interactions = append(r.interactions, in)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	r.played = make([]bool, len(r.interactions))
+	return r, nil
+}
+
+// Remaining returns how many recorded interactions haven't been replayed.
+func (r *ReplayRedditAPI) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, played := range r.played {
+		if !played {
+			n++
+		}
+	}
+	return n
+}
+
+// play finds the recorded interaction for a call, decodes its result
+// into v (if non-nil) and returns the recorded error.
+func (r *ReplayRedditAPI) play(op string, args []any, v any) error {
+	want, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	var in *interaction
+	for i := range r.interactions {
+		if r.played[i] || r.interactions[i].Op != op {
+			continue
+		}
+		var got bytes.Buffer
+		if json.Compact(&got, r.interactions[i].Args) == nil && bytes.Equal(got.Bytes(), want) {
+			r.played[i] = true
+			in = &r.interactions[i]
+			break
+		}
+	}
+	r.mu.Unlock()
+
+	if in == nil {
+		return fmt.Errorf("cassette has no %s call with arguments %s", op, want)
+	}
+	if in.Error != "" {
+		return in.err()
+	}
+	if v != nil {
+		if err := json.Unmarshal(in.Result, v); err != nil {
+			return fmt.Errorf("failed to decode recorded %s: %w", op, err)
+		}
+	}
+	return nil
+}
+
+// err rebuilds a recorded error, as the go-reddit type it was if any.
+func (in *interaction) err() error {
+	if in.Status == 0 {
+		return errors.New(in.Error)
+	}
+
+	req := &http.Request{Method: in.Method, URL: &url.URL{}}
+	if u, err := url.Parse(in.URL); err == nil {
+		req.URL = u
+	}
+	resp := &http.Response{StatusCode: in.Status, Request: req}
+
+	if in.APIErrors == nil {
+		return &reddit.ErrorResponse{Response: resp, Message: in.Message}
+	}
+
+	jsonErr := &reddit.JSONErrorResponse{Response: resp}
+	for _, e := range in.APIErrors {
+		var apiErr reddit.APIError
+		if len(e) == 3 {
+			apiErr = reddit.APIError{Label: e[0], Reason: e[1], Field: e[2]}
+		}
+		jsonErr.JSON.Errors = append(jsonErr.JSON.Errors, apiErr)
+	}
+	return jsonErr
+}
+
+func (r *ReplayRedditAPI) SubmitPost(ctx context.Context, subreddit, title, text string) (*reddit.Submitted, error) {
+	submitted := new(reddit.Submitted)
+	if err := r.play("SubmitPost", []any{subreddit, title, text}, submitted); err != nil {
+		return nil, err
+	}
+	return submitted, nil
+}
+
+func (r *ReplayRedditAPI) GetPost(ctx context.Context, postID string) (*reddit.PostAndComments, error) {
+	post := new(reddit.PostAndComments)
+	if err := r.play("GetPost", []any{postID}, post); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+func (r *ReplayRedditAPI) GetPosts(ctx context.Context, postIDs []string) ([]*reddit.Post, error) {
+	return r.playPosts("GetPosts", []any{postIDs})
+}
+
+func (r *ReplayRedditAPI) DeletePost(ctx context.Context, postID string) error {
+	return r.play("DeletePost", []any{postID}, nil)
+}
+
+func (r *ReplayRedditAPI) SubmitComment(ctx context.Context, parentID, text string) (*reddit.Comment, error) {
+	comment := new(reddit.Comment)
+	if err := r.play("SubmitComment", []any{parentID, text}, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (r *ReplayRedditAPI) EditComment(ctx context.Context, commentID, text string) (*reddit.Comment, error) {
+	comment := new(reddit.Comment)
+	if err := r.play("EditComment", []any{commentID, text}, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (r *ReplayRedditAPI) DeleteComment(ctx context.Context, commentID string) error {
+	return r.play("DeleteComment", []any{commentID}, nil)
+}
+
+func (r *ReplayRedditAPI) ListNewPosts(ctx context.Context, subreddit string, opts *reddit.ListOptions) ([]*reddit.Post, error) {
+	return r.playPosts("ListNewPosts", []any{subreddit, opts})
+}
+
+func (r *ReplayRedditAPI) SearchPosts(ctx context.Context, subreddit, query string) ([]*reddit.Post, error) {
+	return r.playPosts("SearchPosts", []any{subreddit, query})
+}
+
+// playPosts replays a call that returned a listing of posts.
+func (r *ReplayRedditAPI) playPosts(op string, args []any) ([]*reddit.Post, error) {
+	var listing struct {
+		Data struct {
+			Children []struct {
+				Data *reddit.Post `json:"data"`
+			} `json:"children"`
+		} `json:"data"`
+	}
+	if err := r.play(op, args, &listing); err != nil {
+		return nil, err
+	}
+
+	posts := make([]*reddit.Post, len(listing.Data.Children))
+	for i, child := range listing.Data.Children {
+		posts[i] = child.Data
+	}
+	return posts, nil
+}
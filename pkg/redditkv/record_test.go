@@ -0,0 +1,101 @@
+package redditkv
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// session runs the same operations against a client, so a recording and
+// its replay can be compared.
+func session(t *testing.T, client *KVClient) *ValueNode {
+	t.Helper()
+	if err := client.Set("mykey", "root"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := client.Append("mykey", "child", []int{0}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if _, err := client.Keys(); err != nil {
+		t.Fatalf("Keys failed: %v", err)
+	}
+	value, err := client.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	return value
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	recorder, err := NewRecordingRedditAPI(NewMockRedditAPI(), path)
+	if err != nil {
+		t.Fatalf("NewRecordingRedditAPI failed: %v", err)
+	}
+	recorded := session(t, NewWithAPI(recorder, "testsubreddit"))
+	recorder.Close()
+
+	replay, err := NewReplayRedditAPI(path)
+	if err != nil {
+		t.Fatalf("NewReplayRedditAPI failed: %v", err)
+	}
+	replayed := session(t, NewWithAPI(replay, "testsubreddit"))
+
+	if replayed.Version != recorded.Version {
+		t.Errorf("Expected version %s, got %s", recorded.Version, replayed.Version)
+	}
+	if replayed.Value != "root" || len(replayed.Children) != 1 || replayed.Children[0].Value != "child" {
+		t.Errorf("Unexpected tree: %+v", replayed)
+	}
+	if n := replay.Remaining(); n != 0 {
+		t.Errorf("Expected every interaction to be replayed, %d left", n)
+	}
+
+	// Nothing was recorded for a different key
+	if _, err := NewWithAPI(replay, "testsubreddit").Get("other"); err == nil {
+		t.Error("Expected an error for an unrecorded call")
+	}
+}
+
+func TestReplayRedditErrors(t *testing.T) {
+	fake := NewFakeReddit()
+	defer fake.Close()
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	cfg := fake.Config("kvtest")
+	cfg.Record = path
+	client, err := New(cfg, WithSettleDelay(0))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	_ = client.Set("mykey", "root")
+	fake.FailNext("/api/comment", "THREAD_LOCKED")
+	if err := client.Append("mykey", "child", []int{0}); err == nil {
+		t.Fatal("Expected Append to fail")
+	}
+	_, getErr := client.api.GetPost(t.Context(), "nope")
+
+	replay, err := New(Config{Replay: path, Subreddit: "kvtest"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	_ = replay.Set("mykey", "root")
+
+	// Reddit's JSON errors come back as the same types
+	var locked *LockedKeyError
+	if err := replay.Append("mykey", "child", []int{0}); !errors.As(err, &locked) {
+		t.Errorf("Expected LockedKeyError, got %v", err)
+	}
+
+	_, err = replay.api.GetPost(t.Context(), "nope")
+	var errResp *reddit.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response.StatusCode != 404 {
+		t.Errorf("Expected a 404 ErrorResponse, got %v", err)
+	}
+	if err.Error() != getErr.Error() {
+		t.Errorf("Expected %q, got %q", getErr, err)
+	}
+}
@@ -13,9 +13,14 @@ type RedditAPI interface {
 	SubmitPost(ctx context.Context, subreddit, title, text string) (*reddit.Submitted, error)
 	GetPost(ctx context.Context, postID string) (*reddit.PostAndComments, error)
 	DeletePost(ctx context.Context, postID string) error
+	// GetPosts looks up several posts without their comments. Posts that
+	// don't exist are left out.
+	GetPosts(ctx context.Context, postIDs []string) ([]*reddit.Post, error)
 
 	// Comment operations
 	SubmitComment(ctx context.Context, parentID, text string) (*reddit.Comment, error)
+	EditComment(ctx context.Context, commentID, text string) (*reddit.Comment, error)
+	DeleteComment(ctx context.Context, commentID string) error
 
 	// Subreddit operations
 	ListNewPosts(ctx context.Context, subreddit string, opts *reddit.ListOptions) ([]*reddit.Post, error)
@@ -36,7 +41,15 @@ func NewRedditAPI(cfg Config) (RedditAPI, error) {
 		Password: cfg.Password,
 	}
 
-	client, err := reddit.NewClient(credentials, reddit.WithUserAgent("reddit-kv/0.1.0"))
+	opts := []reddit.Opt{reddit.WithUserAgent("reddit-kv/0.1.0")}
+	if cfg.BaseURL != "" {
+		opts = append(opts, reddit.WithBaseURL(cfg.BaseURL))
+	}
+	if cfg.TokenURL != "" {
+		opts = append(opts, reddit.WithTokenURL(cfg.TokenURL))
+	}
+
+	client, err := reddit.NewClient(credentials, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -55,19 +68,66 @@ func (r *redditAPIClient) SubmitPost(ctx context.Context, subreddit, title, text
 
 func (r *redditAPIClient) GetPost(ctx context.Context, postID string) (*reddit.PostAndComments, error) {
 	post, _, err := r.client.Post.Get(ctx, postID)
-	return post, err
+	if err != nil {
+		return nil, err
+	}
+
+	// Big comment trees come back partly, with "more" stubs standing in
+	// for the rest. LoadMoreComments only follows the post's stub, so
+	// stubs further down the tree are moved there one at a time.
+	for post.HasMore() || takeMore(post, post.Comments) {
+		if _, err := r.client.Post.LoadMoreComments(ctx, post); err != nil {
+			return nil, err
+		}
+	}
+	return post, nil
+}
+
+// takeMore moves the first "more" stub found among comments and their
+// replies to the post, so LoadMoreComments fetches what it stands for.
+func takeMore(post *reddit.PostAndComments, comments []*reddit.Comment) bool {
+	for _, comment := range comments {
+		if comment.HasMore() {
+			post.More, comment.Replies.More = comment.Replies.More, nil
+			return true
+		}
+		if takeMore(post, comment.Replies.Comments) {
+			return true
+		}
+	}
+	return false
 }
 
 func (r *redditAPIClient) DeletePost(ctx context.Context, postID string) error {
-	_, err := r.client.Post.Delete(ctx, postID)
+	// api/del wants the full ID and silently ignores anything else
+	_, err := r.client.Post.Delete(ctx, "t3_"+postID)
 	return err
 }
 
+func (r *redditAPIClient) GetPosts(ctx context.Context, postIDs []string) ([]*reddit.Post, error) {
+	fullIDs := make([]string, len(postIDs))
+	for i, id := range postIDs {
+		fullIDs[i] = "t3_" + id
+	}
+	posts, _, err := r.client.Listings.GetPosts(ctx, fullIDs...)
+	return posts, err
+}
+
 func (r *redditAPIClient) SubmitComment(ctx context.Context, parentID, text string) (*reddit.Comment, error) {
 	comment, _, err := r.client.Comment.Submit(ctx, parentID, text)
 	return comment, err
 }
 
+func (r *redditAPIClient) EditComment(ctx context.Context, commentID, text string) (*reddit.Comment, error) {
+	comment, _, err := r.client.Comment.Edit(ctx, commentID, text)
+	return comment, err
+}
+
+func (r *redditAPIClient) DeleteComment(ctx context.Context, commentID string) error {
+	_, err := r.client.Comment.Delete(ctx, commentID)
+	return err
+}
+
 func (r *redditAPIClient) ListNewPosts(ctx context.Context, subreddit string, opts *reddit.ListOptions) ([]*reddit.Post, error) {
 	posts, _, err := r.client.Subreddit.NewPosts(ctx, subreddit, opts)
 	return posts, err
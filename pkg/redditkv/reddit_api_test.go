@@ -0,0 +1,228 @@
+package redditkv
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+func newFakeClient(t *testing.T) (*KVClient, *FakeReddit) {
+	t.Helper()
+	fake := NewFakeReddit()
+	t.Cleanup(fake.Close)
+
+	client, err := New(fake.Config("kvtest"), WithSettleDelay(0))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return client, fake
+}
+
+func TestRedditAPIRoundTrip(t *testing.T) {
+	client, fake := newFakeClient(t)
+
+	if err := client.Set("mykey", "root"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := client.Append("mykey", "child", []int{0}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := client.Append("mykey", "grandchild", []int{0, 0}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	value, err := client.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "root" || len(value.Children) != 1 || value.Children[0].Value != "child" {
+		t.Fatalf("Unexpected tree: %+v", value)
+	}
+	if len(value.Children[0].Children) != 1 || value.Children[0].Children[0].Value != "grandchild" {
+		t.Errorf("Expected nested replies to survive decoding, got %+v", value.Children[0])
+	}
+
+	keys, err := client.Keys()
+	if err != nil {
+		t.Fatalf("Keys failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "mykey" {
+		t.Errorf("Expected [mykey], got %v", keys)
+	}
+
+	if err := client.Delete("mykey"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if fake.Store.GetPostCount() != 0 {
+		t.Errorf("Expected the post to be deleted, %d left", fake.Store.GetPostCount())
+	}
+
+	// One token serves every request
+	if n := fake.TokenRequests(); n != 1 {
+		t.Errorf("Expected 1 token request, got %d", n)
+	}
+}
+
+func TestRedditAPILoadsMoreComments(t *testing.T) {
+	client, fake := newFakeClient(t)
+
+	_ = client.Set("mykey", "root")
+	_ = client.Append("mykey", "child", []int{0})
+	_ = client.Append("mykey", "grandchild", []int{0, 0})
+	for i := range 6 {
+		if _, err := client.XAdd("events", fmt.Sprintf("e%d", i)); err != nil {
+			t.Fatalf("XAdd failed: %v", err)
+		}
+	}
+
+	// Cut the trees short at the root and below it
+	fake.SetInlineComments(2)
+
+	entries, err := client.XRange("events", "-", "+")
+	if err != nil {
+		t.Fatalf("XRange failed: %v", err)
+	}
+	if got := fmt.Sprint(streamValues(entries)); got != "[e0 e1 e2 e3 e4 e5]" {
+		t.Errorf("Expected every entry, got %s", got)
+	}
+
+	_ = client.Append("mykey", "second", nil)
+	value, err := client.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(value.Children) != 2 || len(value.Children[1].Children) != 1 {
+		t.Errorf("Expected the whole tree, got %+v", value)
+	}
+}
+
+func TestRedditAPIEditAndDeleteComment(t *testing.T) {
+	fake := NewFakeReddit()
+	defer fake.Close()
+
+	api, err := NewRedditAPI(fake.Config("kvtest"))
+	if err != nil {
+		t.Fatalf("NewRedditAPI failed: %v", err)
+	}
+
+	submitted, err := api.SubmitPost(t.Context(), "kvtest", "key", "")
+	if err != nil {
+		t.Fatalf("SubmitPost failed: %v", err)
+	}
+	comment, err := api.SubmitComment(t.Context(), submitted.FullID, "before")
+	if err != nil {
+		t.Fatalf("SubmitComment failed: %v", err)
+	}
+
+	edited, err := api.EditComment(t.Context(), comment.FullID, "after")
+	if err != nil {
+		t.Fatalf("EditComment failed: %v", err)
+	}
+	if edited.Body != "after" || edited.Edited == nil {
+		t.Errorf("Expected edited body and timestamp, got %+v", edited)
+	}
+
+	if err := api.DeleteComment(t.Context(), comment.FullID); err != nil {
+		t.Fatalf("DeleteComment failed: %v", err)
+	}
+	pc, err := api.GetPost(t.Context(), submitted.ID)
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if len(pc.Comments) != 0 {
+		t.Errorf("Expected no comments, got %d", len(pc.Comments))
+	}
+}
+
+func TestRedditAPIBadCredentials(t *testing.T) {
+	fake := NewFakeReddit()
+	defer fake.Close()
+
+	cfg := fake.Config("kvtest")
+	cfg.Password = "wrong"
+	client, err := New(cfg, WithSettleDelay(0))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if _, err := client.Keys(); err == nil {
+		t.Error("Expected an error with bad credentials")
+	}
+	if fake.TokenRequests() != 0 {
+		t.Errorf("Expected no tokens to be issued, got %d", fake.TokenRequests())
+	}
+}
+
+func TestRedditAPIErrorDecoding(t *testing.T) {
+	client, fake := newFakeClient(t)
+	_ = client.Set("mykey", "root")
+
+	fake.FailNext("/api/comment", "RATELIMIT")
+	err := client.Append("mykey", "child", []int{0})
+	var jsonErr *reddit.JSONErrorResponse
+	if !errors.As(err, &jsonErr) {
+		t.Fatalf("Expected JSONErrorResponse, got %v", err)
+	}
+	if jsonErr.JSON.Errors[0].Label != "RATELIMIT" {
+		t.Errorf("Expected RATELIMIT, got %s", jsonErr.JSON.Errors[0].Label)
+	}
+
+	// Reddit's lock and archive errors map onto typed errors
+	posts, _ := fake.Store.SearchPosts(t.Context(), "kvtest", "mykey")
+	api, _ := NewRedditAPI(fake.Config("kvtest"))
+
+	fake.Store.SetPostLocked(posts[0].ID, true)
+	_, err = api.SubmitComment(t.Context(), posts[0].FullID, "child")
+	var locked *LockedKeyError
+	if !errors.As(classifyWriteError("mykey", err), &locked) {
+		t.Errorf("Expected THREAD_LOCKED, got %v", err)
+	}
+
+	fake.Store.SetPostLocked(posts[0].ID, false)
+	fake.Store.SetPostArchived(posts[0].ID, true)
+	_, err = api.SubmitComment(t.Context(), posts[0].FullID, "child")
+	var archived *ArchivedKeyError
+	if !errors.As(classifyWriteError("mykey", err), &archived) {
+		t.Errorf("Expected TOO_OLD, got %v", err)
+	}
+}
+
+func TestRedditAPIPostNotFound(t *testing.T) {
+	fake := NewFakeReddit()
+	defer fake.Close()
+
+	api, err := NewRedditAPI(fake.Config("kvtest"))
+	if err != nil {
+		t.Fatalf("NewRedditAPI failed: %v", err)
+	}
+
+	_, err = api.GetPost(t.Context(), "nope")
+	var errResp *reddit.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response.StatusCode != 404 {
+		t.Errorf("Expected a 404 ErrorResponse, got %v", err)
+	}
+}
+
+func TestRedditAPIGetPosts(t *testing.T) {
+	client, _ := newFakeClient(t)
+	_ = client.MSet(map[string]string{"a": "1", "b": "2"})
+
+	var ids []string
+	for _, key := range []string{"a", "b"} {
+		post, err := client.findPostByTitle(key)
+		if err != nil || post == nil {
+			t.Fatalf("findPostByTitle failed: %v", err)
+		}
+		ids = append(ids, post.ID)
+	}
+
+	posts, err := client.api.GetPosts(client.ctx, append(ids, "missing"))
+	if err != nil {
+		t.Fatalf("GetPosts failed: %v", err)
+	}
+	if len(posts) != 2 || posts[0].Title != "a" || posts[1].Title != "b" {
+		t.Errorf("Expected posts a and b, got %+v", posts)
+	}
+}
@@ -0,0 +1,246 @@
+package redditkv
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// StreamEntry is one entry in a stream.
+//
+// A stream is an ordinary key read as a log: each top-level comment is an
+// entry, so values written with Append(key, v, nil) are entries too.
+// Entry IDs look like Redis stream IDs, "<unix ms>-<comment ID>". The
+// timestamp allows ranges by time; the comment ID, which only ever grows,
+// orders entries written in the same millisecond.
+type StreamEntry struct {
+	ID    string    `json:"id"`
+	Value string    `json:"value"`
+	Time  time.Time `json:"time"`
+
+	comment *reddit.Comment
+}
+
+// TrimOptions says which entries XTrim removes. Zero fields don't limit.
+type TrimOptions struct {
+	// MaxLen keeps at most this many of the newest entries.
+	MaxLen int
+	// MaxAge removes entries older than this.
+	MaxAge time.Duration
+}
+
+// XAdd appends an entry to a stream, creating the stream if it doesn't
+// exist yet, and returns the entry's ID.
+func (c *KVClient) XAdd(key, value string) (string, error) {
+	comment, err := c.appendComment(key, value, nil)
+	var notFound *KeyNotFoundError
+	if errors.As(err, &notFound) {
+		created, err := c.SetIfAbsent(key, value)
+		if err != nil {
+			return "", err
+		}
+		if created {
+			entries, err := c.streamEntries(key)
+			if err != nil {
+				return "", err
+			}
+			if len(entries) == 0 {
+				return "", fmt.Errorf("failed to create stream: %s", key)
+			}
+			return entries[0].ID, nil
+		}
+		// Someone else created it first; add to theirs
+		comment, err = c.appendComment(key, value, nil)
+	}
+	if err != nil {
+		return "", err
+	}
+	return entryFrom(comment).ID, nil
+}
+
+// XRange returns a stream's entries with IDs from start to end,
+// inclusive, oldest first. "-" and "+" stand for the first and last
+// entry. An ID may leave out its comment part, as in "1700000000000", to
+// select by time alone; a leading "(" makes a bound exclusive, so
+// XRange(key, "("+lastID, "+") reads the entries since lastID.
+func (c *KVClient) XRange(key, start, end string) ([]StreamEntry, error) {
+	return c.xrange(key, start, end, 0, false)
+}
+
+// XRevRange returns a stream's entries from end down to start, newest
+// first, stopping after count entries if count is positive. Bounds are
+// as for XRange. XRevRange(key, "+", "-", n) returns the last n entries.
+func (c *KVClient) XRevRange(key, end, start string, count int) ([]StreamEntry, error) {
+	return c.xrange(key, start, end, count, true)
+}
+
+func (c *KVClient) xrange(key, start, end string, count int, reverse bool) ([]StreamEntry, error) {
+	lo, err := parseStreamBound(start, false)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := parseStreamBound(end, true)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := c.streamEntries(key)
+	if err != nil {
+		return nil, err
+	}
+	if reverse {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+
+	out := []StreamEntry{}
+	for _, entry := range entries {
+		if !lo.lowerAdmits(entry) || !hi.upperAdmits(entry) {
+			continue
+		}
+		out = append(out, entry)
+		if count > 0 && len(out) == count {
+			break
+		}
+	}
+	return out, nil
+}
+
+// XTrim removes a stream's oldest entries until it satisfies opts, and
+// returns how many it removed.
+func (c *KVClient) XTrim(key string, opts TrimOptions) (int, error) {
+	defer c.invalidate(key)
+
+	entries, err := c.streamEntries(key)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-opts.MaxAge)
+	removed := 0
+	for i, entry := range entries {
+		tooMany := opts.MaxLen > 0 && len(entries)-i > opts.MaxLen
+		tooOld := opts.MaxAge > 0 && entry.Time.Before(cutoff)
+		if !tooMany && !tooOld {
+			// Entries are oldest first, so the rest are kept too
+			break
+		}
+		if err := c.api.DeleteComment(c.ctx, entry.comment.FullID); err != nil {
+			return removed, fmt.Errorf("failed to trim stream: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// streamEntries returns a stream's entries, oldest first. Deleted and
+// removed comments are skipped.
+func (c *KVClient) streamEntries(key string) ([]StreamEntry, error) {
+	post, err := c.findPostByTitle(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find key: %w", err)
+	}
+	if post == nil {
+		return nil, &KeyNotFoundError{Key: key}
+	}
+
+	postAndComments, err := c.api.GetPost(c.ctx, post.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+
+	var entries []StreamEntry
+	for _, comment := range postAndComments.Comments {
+		if isRemovedComment(comment) {
+			continue
+		}
+		entries = append(entries, entryFrom(comment))
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return idLess(entries[i].comment.ID, entries[j].comment.ID)
+	})
+	return entries, nil
+}
+
+func entryFrom(comment *reddit.Comment) StreamEntry {
+	var created time.Time
+	if comment.Created != nil {
+		created = comment.Created.Time
+	}
+	return StreamEntry{
+		ID:      fmt.Sprintf("%d-%s", created.UnixMilli(), comment.ID),
+		Value:   comment.Body,
+		Time:    created,
+		comment: comment,
+	}
+}
+
+// streamBound is one end of a range. An empty commentID with ms set
+// matches every entry in that millisecond.
+type streamBound struct {
+	ms        int64
+	commentID string
+	open      bool // "-" or "+": no bound at all
+	exclusive bool // "(": matching entries are left out
+}
+
+func parseStreamBound(id string, upper bool) (streamBound, error) {
+	if (id == "-" && !upper) || (id == "+" && upper) {
+		return streamBound{open: true}, nil
+	}
+
+	bound, exclusive := strings.CutPrefix(id, "(")
+	msPart, commentID, _ := strings.Cut(bound, "-")
+	ms, err := strconv.ParseInt(msPart, 10, 64)
+	if err != nil || (bound != msPart && commentID == "") {
+		return streamBound{}, &InvalidStreamIDError{ID: id}
+	}
+	return streamBound{ms: ms, commentID: commentID, exclusive: exclusive}, nil
+}
+
+// compare orders an entry against the bound: negative if the entry comes
+// before it, zero if the bound matches it, positive if it comes after.
+func (b streamBound) compare(entry StreamEntry) int {
+	ms := entry.Time.UnixMilli()
+	switch {
+	case ms < b.ms:
+		return -1
+	case ms > b.ms:
+		return 1
+	case b.commentID == "" || b.commentID == entry.comment.ID:
+		return 0
+	case idLess(entry.comment.ID, b.commentID):
+		return -1
+	default:
+		return 1
+	}
+}
+
+// lowerAdmits reports whether a lower bound admits the entry.
+func (b streamBound) lowerAdmits(entry StreamEntry) bool {
+	if b.open {
+		return true
+	}
+	if b.exclusive {
+		return b.compare(entry) > 0
+	}
+	return b.compare(entry) >= 0
+}
+
+// upperAdmits reports whether an upper bound admits the entry.
+func (b streamBound) upperAdmits(entry StreamEntry) bool {
+	if b.open {
+		return true
+	}
+	if b.exclusive {
+		return b.compare(entry) < 0
+	}
+	return b.compare(entry) <= 0
+}
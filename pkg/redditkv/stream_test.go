@@ -0,0 +1,108 @@
+package redditkv
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func streamValues(entries []StreamEntry) []string {
+	values := make([]string, len(entries))
+	for i, entry := range entries {
+		values[i] = entry.Value
+	}
+	return values
+}
+
+func TestStreamRanges(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit")
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		id, err := client.XAdd("events", fmt.Sprintf("e%d", i))
+		if err != nil {
+			t.Fatalf("XAdd failed: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	// Plain appends are entries too
+	_ = client.Append("events", "e5", nil)
+
+	all, err := client.XRange("events", "-", "+")
+	if err != nil {
+		t.Fatalf("XRange failed: %v", err)
+	}
+	if got := fmt.Sprint(streamValues(all)); got != "[e0 e1 e2 e3 e4 e5]" {
+		t.Errorf("Expected every entry, got %s", got)
+	}
+	if all[0].ID != ids[0] || all[4].ID != ids[4] {
+		t.Errorf("Expected XAdd's IDs, got %s and %s", all[0].ID, all[4].ID)
+	}
+
+	between, _ := client.XRange("events", ids[1], ids[3])
+	if got := fmt.Sprint(streamValues(between)); got != "[e1 e2 e3]" {
+		t.Errorf("Expected e1 to e3, got %s", got)
+	}
+
+	since, _ := client.XRange("events", "("+ids[3], "+")
+	if got := fmt.Sprint(streamValues(since)); got != "[e4 e5]" {
+		t.Errorf("Expected the entries after e3, got %s", got)
+	}
+
+	last, err := client.XRevRange("events", "+", "-", 2)
+	if err != nil {
+		t.Fatalf("XRevRange failed: %v", err)
+	}
+	if got := fmt.Sprint(streamValues(last)); got != "[e5 e4]" {
+		t.Errorf("Expected the last two entries, newest first, got %s", got)
+	}
+
+	// A bare timestamp selects by time
+	ms := all[0].Time.Add(-time.Second).UnixMilli()
+	byTime, _ := client.XRange("events", fmt.Sprint(ms), "+")
+	if len(byTime) != 6 {
+		t.Errorf("Expected every entry after %d, got %d", ms, len(byTime))
+	}
+
+	var invalid *InvalidStreamIDError
+	if _, err := client.XRange("events", "nope", "+"); !errors.As(err, &invalid) {
+		t.Errorf("Expected InvalidStreamIDError, got %v", err)
+	}
+	var notFound *KeyNotFoundError
+	if _, err := client.XRange("missing", "-", "+"); !errors.As(err, &notFound) {
+		t.Errorf("Expected KeyNotFoundError, got %v", err)
+	}
+}
+
+func TestStreamTrim(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit")
+	for i := 0; i < 3; i++ {
+		_, _ = client.XAdd("events", fmt.Sprintf("old%d", i))
+	}
+	time.Sleep(30 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		_, _ = client.XAdd("events", fmt.Sprintf("new%d", i))
+	}
+
+	removed, err := client.XTrim("events", TrimOptions{MaxLen: 5})
+	if err != nil {
+		t.Fatalf("XTrim failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 entry removed, got %d", removed)
+	}
+
+	removed, err = client.XTrim("events", TrimOptions{MaxAge: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("XTrim failed: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 entries removed, got %d", removed)
+	}
+
+	entries, _ := client.XRange("events", "-", "+")
+	if got := fmt.Sprint(streamValues(entries)); got != "[new0 new1 new2]" {
+		t.Errorf("Expected only the new entries, got %s", got)
+	}
+}
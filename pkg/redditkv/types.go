@@ -1,5 +1,11 @@
 package redditkv
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
 // ValueNode represents a node in the value tree.
 // A single comment becomes a scalar (no children).
 // A linear thread becomes an array (each node has one child).
@@ -7,6 +13,14 @@ package redditkv
 type ValueNode struct {
 	Value    string      `json:"value"`
 	Children []ValueNode `json:"children"`
+
+	// Version identifies the key's current contents. It is only set on
+	// the root node returned by Get; pass it to CompareAndSet.
+	Version string `json:"version,omitempty"`
+
+	// Meta holds the key's metadata, as stored by SetWithMeta.
+	// Like Version, it is only set on the root node.
+	Meta map[string]string `json:"meta,omitempty"`
 }
 
 // Config holds the configuration for the reddit-kv client.
@@ -20,6 +34,24 @@ type Config struct {
 	// Target subreddit (the "database")
 	Subreddit string `json:"subreddit"`
 
+	// Backend selects where data lives: empty or "reddit" for Reddit
+	// itself, or "local:<path>" for an offline JSON database file.
+	Backend string `json:"backend,omitempty"`
+
+	// Override Reddit's API and OAuth token endpoints, e.g. to point
+	// at a FakeReddit server. Empty means the real ones.
+	BaseURL  string `json:"base_url,omitempty"`
+	TokenURL string `json:"token_url,omitempty"`
+
+	// Record writes every API call to this cassette file; Replay serves
+	// calls from one instead of talking to a backend. Not saved.
+	Record string `json:"-"`
+	Replay string `json:"-"`
+
+	// WriteBehind makes the CLI queue writes in a local file instead of
+	// sending them to Reddit; 'reddit-kv flush' sends them later.
+	WriteBehind bool `json:"write_behind,omitempty"`
+
 	// OAuth tokens (managed internally)
 	AccessToken  string `json:"access_token,omitempty"`
 	RefreshToken string `json:"refresh_token,omitempty"`
@@ -52,6 +84,12 @@ type Client interface {
 	Exists(key string) (bool, error)
 }
 
+// ErrClosed is returned by calls made on an AsyncClient after Close.
+var ErrClosed = errors.New("client is closed")
+
+// ErrQueueEmpty is returned by Claim when no job is ready.
+var ErrQueueEmpty = errors.New("queue is empty")
+
 // KeyNotFoundError is returned when a key does not exist.
 type KeyNotFoundError struct {
 	Key string
@@ -69,3 +107,129 @@ type InvalidPathError struct {
 func (e *InvalidPathError) Error() string {
 	return "invalid path"
 }
+
+// ArchivedKeyError is returned when a key's post has been archived by Reddit
+// and no longer accepts new values.
+type ArchivedKeyError struct {
+	Key string
+}
+
+func (e *ArchivedKeyError) Error() string {
+	return "key is archived: " + e.Key
+}
+
+// LockedKeyError is returned when a moderator has locked a key's post.
+type LockedKeyError struct {
+	Key string
+}
+
+func (e *LockedKeyError) Error() string {
+	return "key is locked: " + e.Key
+}
+
+// RemovedValueError is returned when part of a key's value tree was removed
+// by a moderator or deleted. Path points at the first removed node.
+type RemovedValueError struct {
+	Key  string
+	Path []int
+}
+
+func (e *RemovedValueError) Error() string {
+	return "value removed: " + e.Key
+}
+
+// ConflictError is returned by conditional writes when the key was changed
+// by someone else since the expected version was read.
+type ConflictError struct {
+	Key      string
+	Expected string
+	Actual   string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("version conflict on key %s: expected %q, found %q", e.Key, e.Expected, e.Actual)
+}
+
+// LockHeldError is returned by Lock when another holder has an unexpired lease.
+type LockHeldError struct {
+	Name    string
+	Holder  string
+	Expires time.Time
+}
+
+func (e *LockHeldError) Error() string {
+	return fmt.Sprintf("lock %s is held by %s until %s", e.Name, e.Holder, e.Expires.Format(time.RFC3339))
+}
+
+// LeaseLostError is returned when a lease expired or was released before
+// it could be refreshed.
+type LeaseLostError struct {
+	Name string
+}
+
+func (e *LeaseLostError) Error() string {
+	return "lease lost: " + e.Name
+}
+
+// ClaimLostError is returned when acknowledging a job whose claim expired
+// and was taken over by another worker, or whose job is already gone.
+type ClaimLostError struct {
+	Queue string
+	JobID string
+}
+
+func (e *ClaimLostError) Error() string {
+	return fmt.Sprintf("claim lost: job %s in queue %s", e.JobID, e.Queue)
+}
+
+// WrongTypeError is returned when a key holds a different data type than
+// the operation expects, such as Incr on a key that isn't a counter.
+type WrongTypeError struct {
+	Key  string
+	Type string
+}
+
+func (e *WrongTypeError) Error() string {
+	return fmt.Sprintf("key %s is not a %s", e.Key, e.Type)
+}
+
+// FieldNotFoundError is returned by HGet when a hash has no such field.
+type FieldNotFoundError struct {
+	Key   string
+	Field string
+}
+
+func (e *FieldNotFoundError) Error() string {
+	return fmt.Sprintf("field %s not found in %s", e.Field, e.Key)
+}
+
+// InvalidStreamIDError is returned when a stream range bound isn't "-",
+// "+", or an entry ID.
+type InvalidStreamIDError struct {
+	ID string
+}
+
+func (e *InvalidStreamIDError) Error() string {
+	return "invalid stream ID: " + e.ID
+}
+
+// BatchError is returned by Batch.Commit when one of its operations
+// failed. The batch then tries to undo everything it changed; Undone
+// reports whether that worked, and the BatchReport says what is left.
+type BatchError struct {
+	Index  int
+	Key    string
+	Err    error
+	Undone bool
+}
+
+func (e *BatchError) Error() string {
+	if e.Undone {
+		return fmt.Sprintf("batch operation %d on key %s failed, batch undone: %v", e.Index, e.Key, e.Err)
+	}
+	return fmt.Sprintf("batch operation %d on key %s failed, batch partly applied: %v", e.Index, e.Key, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,126 @@
+package redditkv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// versionOf derives a key's version token from its post ID and a hash of
+// the comment tree. Set recreates the post, so overwrites change the ID;
+// appends and edits change the hash.
+func versionOf(pc *reddit.PostAndComments) string {
+	h := sha256.New()
+	hashComments(h, pc.Comments)
+	return pc.Post.ID + "-" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func hashComments(h hash.Hash, comments []*reddit.Comment) {
+	for _, comment := range comments {
+		fmt.Fprintf(h, "%s\x00%s\x00%d\x00", comment.ID, comment.Body, len(comment.Replies.Comments))
+		hashComments(h, comment.Replies.Comments)
+	}
+}
+
+// currentVersion returns the key's post and version token.
+// Both are empty if the key doesn't exist.
+func (c *KVClient) currentVersion(key string) (*reddit.Post, string, error) {
+	postAndComments, err := c.currentPost(key)
+	if err != nil || postAndComments == nil {
+		return nil, "", err
+	}
+	return postAndComments.Post, versionOf(postAndComments), nil
+}
+
+// currentPost fetches the key's post with its comments, or returns nil
+// if the key doesn't exist.
+func (c *KVClient) currentPost(key string) (*reddit.PostAndComments, error) {
+	post, err := c.findPostByTitle(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find key: %w", err)
+	}
+	if post == nil {
+		return nil, nil
+	}
+
+	postAndComments, err := c.api.GetPost(c.ctx, post.ID)
+	if err != nil {
+		// A concurrent overwrite may have deleted the post since the
+		// search; if so, the key's post is whatever replaced it
+		again, findErr := c.findPostByTitle(key)
+		if findErr != nil || (again != nil && again.ID == post.ID) {
+			return nil, fmt.Errorf("failed to get post: %w", err)
+		}
+		if again == nil {
+			return nil, nil
+		}
+		if postAndComments, err = c.api.GetPost(c.ctx, again.ID); err != nil {
+			return nil, fmt.Errorf("failed to get post: %w", err)
+		}
+	}
+	return postAndComments, nil
+}
+
+// CompareAndSet overwrites a key with a scalar value only if its current
+// version (as returned on the root node by Get) equals expectedVersion.
+// An empty expectedVersion requires that the key doesn't exist yet, with
+// the same race protection as SetIfAbsent.
+// If someone else wrote first, a ConflictError is returned. Writers that
+// pass the version check together are settled like SetIfAbsent: the
+// oldest new post wins and the others back out with a ConflictError.
+func (c *KVClient) CompareAndSet(key, expectedVersion, value string) error {
+	return c.CompareAndSetWithMeta(key, expectedVersion, value, nil)
+}
+
+// CompareAndSetWithMeta is like CompareAndSet but also stores metadata
+// alongside the key.
+func (c *KVClient) CompareAndSetWithMeta(key, expectedVersion, value string, meta map[string]string) error {
+	if expectedVersion == "" {
+		ok, err := c.SetIfAbsentWithMeta(key, value, meta)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			_, actual, _ := c.currentVersion(key)
+			return &ConflictError{Key: key, Actual: actual}
+		}
+		return nil
+	}
+
+	post, actual, err := c.currentVersion(key)
+	if err != nil {
+		return err
+	}
+
+	if actual != expectedVersion {
+		return &ConflictError{Key: key, Expected: expectedVersion, Actual: actual}
+	}
+
+	// Anyone else who read the same version is deleting this post too;
+	// only the race check below decides between us. A failed delete is
+	// only a conflict if the key has moved on meanwhile.
+	if err := c.api.DeletePost(c.ctx, post.ID); err != nil {
+		if _, now, findErr := c.currentVersion(key); findErr == nil && now != expectedVersion {
+			return &ConflictError{Key: key, Expected: expectedVersion, Actual: now}
+		}
+		return fmt.Errorf("failed to delete existing key: %w", err)
+	}
+
+	submitted, err := c.replace(key, value, meta, nil)
+	if err != nil {
+		return err
+	}
+
+	won, err := c.resolveRace(key, submitted.ID)
+	if err != nil {
+		return err
+	}
+	if !won {
+		_, actual, _ := c.currentVersion(key)
+		return &ConflictError{Key: key, Expected: expectedVersion, Actual: actual}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package redditkv
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetReturnsVersion(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	_ = client.Set("mykey", "value")
+	first, err := client.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if first.Version == "" {
+		t.Fatal("Expected a version token")
+	}
+
+	// Appending changes the version
+	_ = client.Append("mykey", "more", nil)
+	second, _ := client.Get("mykey")
+	if second.Version == first.Version {
+		t.Error("Expected version to change after Append")
+	}
+
+	// Reading again without writes doesn't
+	third, _ := client.Get("mykey")
+	if third.Version != second.Version {
+		t.Error("Expected version to be stable between reads")
+	}
+}
+
+func TestCompareAndSet(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	_ = client.Set("mykey", "v1")
+	value, _ := client.Get("mykey")
+
+	if err := client.CompareAndSet("mykey", value.Version, "v2"); err != nil {
+		t.Fatalf("CompareAndSet failed: %v", err)
+	}
+
+	// The old version is stale now
+	err := client.CompareAndSet("mykey", value.Version, "v3")
+	conflict, ok := err.(*ConflictError)
+	if !ok {
+		t.Fatalf("Expected ConflictError, got %T: %v", err, err)
+	}
+	if conflict.Actual == "" || conflict.Actual == value.Version {
+		t.Errorf("Unexpected actual version %q", conflict.Actual)
+	}
+
+	value, _ = client.Get("mykey")
+	if value.Value != "v2" {
+		t.Errorf("Expected value 'v2', got '%s'", value.Value)
+	}
+}
+
+func TestCompareAndSetDeleteFails(t *testing.T) {
+	fail, _ := failing("DeletePost", 1, errors.New("delete failed"))
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit", WithMiddleware(fail))
+
+	_ = client.Set("mykey", "v1")
+	value, _ := client.Get("mykey")
+
+	err := client.CompareAndSet("mykey", value.Version, "v2")
+	if err == nil || errors.As(err, new(*ConflictError)) {
+		t.Fatalf("Expected the delete error, got %T: %v", err, err)
+	}
+
+	value, _ = client.Get("mykey")
+	if value.Value != "v1" {
+		t.Errorf("Expected value 'v1', got '%s'", value.Value)
+	}
+}
+
+func TestCompareAndSetAbsent(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	if err := client.CompareAndSet("mykey", "", "v1"); err != nil {
+		t.Fatalf("CompareAndSet on absent key failed: %v", err)
+	}
+
+	if err := client.CompareAndSet("mykey", "", "v2"); err == nil {
+		t.Error("Expected ConflictError when key already exists")
+	}
+}
+
+func TestCompareAndSetConcurrent(t *testing.T) {
+	client, fake := newFakeClient(t)
+	_ = client.Set("mykey", "v0")
+	value, _ := client.Get("mykey")
+
+	const writers = 5
+	errs := make([]error, writers)
+	var wg sync.WaitGroup
+	for i := range writers {
+		writer, err := New(fake.Config("kvtest"), WithSettleDelay(20*time.Millisecond))
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = writer.CompareAndSet("mykey", value.Version, fmt.Sprintf("v%d", i+1))
+		}()
+	}
+	wg.Wait()
+
+	winner := ""
+	for i, err := range errs {
+		var conflict *ConflictError
+		switch {
+		case err == nil:
+			if winner != "" {
+				t.Errorf("Expected one winner, got %s and v%d", winner, i+1)
+			}
+			winner = fmt.Sprintf("v%d", i+1)
+		case !errors.As(err, &conflict):
+			t.Errorf("Expected ConflictError, got %v", err)
+		}
+	}
+	if winner == "" {
+		t.Fatal("Expected one writer to win")
+	}
+
+	value, err := client.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != winner {
+		t.Errorf("Expected the winner's value %s, got %s", winner, value.Value)
+	}
+	if n := fake.Store.GetPostCount(); n != 1 {
+		t.Errorf("Expected losers to back out, got %d posts", n)
+	}
+}
@@ -0,0 +1,249 @@
+package redditkv
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// defaultPollInterval is how often watches poll Reddit unless
+// WithPollInterval says otherwise.
+const defaultPollInterval = 10 * time.Second
+
+// ChangeKind says how a watched key changed.
+type ChangeKind string
+
+const (
+	// ChangeCreated means the key appeared.
+	ChangeCreated ChangeKind = "created"
+	// ChangeReplaced means the key was overwritten, e.g. by Set.
+	ChangeReplaced ChangeKind = "replaced"
+	// ChangeAppended means values were added to the key's tree.
+	ChangeAppended ChangeKind = "appended"
+	// ChangeModified means the tree changed without growing, e.g. a
+	// comment was edited or removed.
+	ChangeModified ChangeKind = "modified"
+	// ChangeDeleted means the key was deleted.
+	ChangeDeleted ChangeKind = "deleted"
+)
+
+// ChangeEvent describes one change to a key.
+type ChangeEvent struct {
+	Key  string     `json:"key"`
+	Kind ChangeKind `json:"kind"`
+	// Version is the key's new version token; empty once deleted.
+	Version string `json:"version,omitempty"`
+	// Value is the key's new value tree. It is nil for deletions, for
+	// trees with removed comments, and for WatchKeys events.
+	Value *ValueNode `json:"value,omitempty"`
+}
+
+// WithPollInterval sets how often Watch and WatchKeys poll Reddit.
+func WithPollInterval(d time.Duration) Option {
+	return func(c *KVClient) {
+		c.pollInterval = d
+	}
+}
+
+// Watch polls a key for changes and sends an event for each one it sees
+// until ctx is done, then closes the channel. Each poll fetches the
+// key's post and compares its comment count and version hash; the key is
+// only searched for again when its post disappears. Changes between two
+// polls are reported as one event. Polls that fail are retried at the
+// next interval.
+func (c *KVClient) Watch(ctx context.Context, key string) (<-chan ChangeEvent, error) {
+	w := &keyWatch{client: c, key: key}
+	if err := w.resolve(); err != nil {
+		return nil, err
+	}
+
+	events := make(chan ChangeEvent)
+	go func() {
+		defer close(events)
+		c.poll(ctx, func() bool {
+			event, changed := w.poll()
+			return !changed || send(ctx, events, event)
+		})
+	}()
+	return events, nil
+}
+
+// WatchKeys polls the subreddit's newest posts and sends an event for
+// each key that is created or replaced until ctx is done, then closes the
+// channel. Locks, channels and queues aren't keys and are left out.
+// Deletions can't be told apart from posts dropping out of the
+// newest listing, so they aren't reported.
+func (c *KVClient) WatchKeys(ctx context.Context) (<-chan ChangeEvent, error) {
+	_, seen, err := c.newestPosts()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan ChangeEvent)
+	go func() {
+		defer close(events)
+		c.poll(ctx, func() bool {
+			posts, newest, err := c.newestPosts()
+			if err != nil {
+				return true
+			}
+
+			// Oldest first, so events come in the order keys were written
+			for _, post := range slices.Backward(posts) {
+				if isBookkeeping(post.Title) || post.ID != newest[post.Title] || post.ID == seen[post.Title] {
+					continue
+				}
+				kind := ChangeReplaced
+				if seen[post.Title] == "" {
+					kind = ChangeCreated
+				}
+				seen[post.Title] = post.ID
+				if !send(ctx, events, ChangeEvent{Key: post.Title, Kind: kind}) {
+					return false
+				}
+			}
+			return true
+		})
+	}()
+	return events, nil
+}
+
+// newestPosts lists the subreddit's newest posts, newest first, and maps
+// their titles to post IDs. For duplicated keys the newest post wins.
+func (c *KVClient) newestPosts() ([]*reddit.Post, map[string]string, error) {
+	posts, err := c.api.ListNewPosts(c.ctx, c.subreddit, &reddit.ListOptions{Limit: 100})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make(map[string]string)
+	for _, post := range posts {
+		if _, ok := ids[post.Title]; !ok {
+			ids[post.Title] = post.ID
+		}
+	}
+	return posts, ids, nil
+}
+
+// poll calls fn every poll interval until ctx is done or fn returns false.
+func (c *KVClient) poll(ctx context.Context, fn func() bool) {
+	interval := c.pollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !fn() {
+			return
+		}
+	}
+}
+
+func send(ctx context.Context, events chan<- ChangeEvent, event ChangeEvent) bool {
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// keyWatch is the state of a Watch between polls.
+type keyWatch struct {
+	client *KVClient
+	key    string
+
+	postID      string // empty while the key doesn't exist
+	numComments int
+	version     string
+}
+
+// resolve finds the key's current post.
+func (w *keyWatch) resolve() error {
+	post, err := w.client.findPostByTitle(w.key)
+	if err != nil {
+		return err
+	}
+	if post == nil {
+		return nil
+	}
+	pc, err := w.client.api.GetPost(w.client.ctx, post.ID)
+	if err != nil {
+		return err
+	}
+	w.update(pc)
+	return nil
+}
+
+func (w *keyWatch) update(pc *reddit.PostAndComments) {
+	w.postID = pc.Post.ID
+	w.numComments = pc.Post.NumberOfComments
+	w.version = versionOf(pc)
+}
+
+// poll checks the key once and returns the change, if there was one.
+func (w *keyWatch) poll() (ChangeEvent, bool) {
+	c := w.client
+
+	if w.postID != "" {
+		pc, err := c.api.GetPost(c.ctx, w.postID)
+		// Reddit keeps serving deleted posts, with their body blanked
+		if err == nil && !isRemovedBody(pc.Post.Body) {
+			kind := ChangeModified
+			switch version := versionOf(pc); {
+			case version == w.version:
+				return ChangeEvent{}, false
+			case pc.Post.NumberOfComments > w.numComments:
+				kind = ChangeAppended
+			}
+			w.update(pc)
+			return w.event(kind, pc), true
+		}
+	}
+
+	// The post is gone or there wasn't one: look for the key again
+	post, err := c.findPostByTitle(w.key)
+	if err != nil {
+		return ChangeEvent{}, false
+	}
+	if post == nil {
+		if w.postID == "" {
+			return ChangeEvent{}, false
+		}
+		w.postID, w.numComments, w.version = "", 0, ""
+		return ChangeEvent{Key: w.key, Kind: ChangeDeleted}, true
+	}
+
+	if post.ID == w.postID {
+		// Still there; fetching it just failed
+		return ChangeEvent{}, false
+	}
+
+	pc, err := c.api.GetPost(c.ctx, post.ID)
+	if err != nil {
+		return ChangeEvent{}, false
+	}
+	kind := ChangeReplaced
+	if w.postID == "" {
+		kind = ChangeCreated
+	}
+	w.update(pc)
+	return w.event(kind, pc), true
+}
+
+func (w *keyWatch) event(kind ChangeKind, pc *reddit.PostAndComments) ChangeEvent {
+	event := ChangeEvent{Key: w.key, Kind: kind, Version: w.version}
+	if value, err := valueFromPost(w.key, pc); err == nil {
+		event.Value = value
+	}
+	return event
+}
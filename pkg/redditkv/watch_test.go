@@ -0,0 +1,99 @@
+package redditkv
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// nextEvent waits for the next event from a watch.
+func nextEvent(t *testing.T, events <-chan ChangeEvent) ChangeEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("Expected an event, channel was closed")
+		}
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+	return ChangeEvent{}
+}
+
+func TestWatch(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit", WithPollInterval(5*time.Millisecond))
+	other := NewWithAPI(mock, "testsubreddit")
+	_ = other.Set("mykey", "root")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := client.Watch(ctx, "mykey")
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+
+	_ = other.Append("mykey", "child", []int{0})
+	event := nextEvent(t, events)
+	if event.Kind != ChangeAppended || len(event.Value.Children) != 1 {
+		t.Errorf("Expected an append with the new child, got %+v", event)
+	}
+
+	post, _ := other.findPostByTitle("mykey")
+	pc, _ := mock.GetPost(ctx, post.ID)
+	_, _ = mock.EditComment(ctx, pc.Comments[0].FullID, "edited")
+	if event := nextEvent(t, events); event.Kind != ChangeModified || event.Value.Value != "edited" {
+		t.Errorf("Expected a modification, got %+v", event)
+	}
+
+	_ = other.Set("mykey", "new")
+	if event := nextEvent(t, events); event.Kind != ChangeReplaced || event.Value.Value != "new" {
+		t.Errorf("Expected a replacement, got %+v", event)
+	}
+
+	_ = other.Delete("mykey")
+	if event := nextEvent(t, events); event.Kind != ChangeDeleted || event.Value != nil {
+		t.Errorf("Expected a deletion, got %+v", event)
+	}
+
+	_ = other.Set("mykey", "again")
+	if event := nextEvent(t, events); event.Kind != ChangeCreated || event.Version == "" {
+		t.Errorf("Expected a creation, got %+v", event)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestWatchKeys(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit", WithPollInterval(5*time.Millisecond))
+	other := NewWithAPI(mock, "testsubreddit")
+	_ = other.Set("existing", "value")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := client.WatchKeys(ctx)
+	if err != nil {
+		t.Fatalf("WatchKeys failed: %v", err)
+	}
+
+	_ = other.Set("a", "1")
+	// Bookkeeping posts aren't keys
+	_, _ = other.Lock("job", time.Minute)
+	_, _ = other.Publish("news", "hello")
+	_ = other.Enqueue("work", "task")
+	_ = other.Set("b", "2")
+	for _, key := range []string{"a", "b"} {
+		if event := nextEvent(t, events); event.Key != key || event.Kind != ChangeCreated {
+			t.Errorf("Expected %s to be created, got %+v", key, event)
+		}
+	}
+
+	_ = other.Set("existing", "changed")
+	if event := nextEvent(t, events); event.Key != "existing" || event.Kind != ChangeReplaced {
+		t.Errorf("Expected 'existing' to be replaced, got %+v", event)
+	}
+}
@@ -0,0 +1,109 @@
+package redditkv
+
+import "github.com/vartanbeno/go-reddit/v2/reddit"
+
+// The wire* helpers encode go-reddit types the way Reddit's API does, so
+// go-reddit can decode them again. Marshalling the structs directly
+// doesn't round-trip: Replies encodes as a plain array, not a listing.
+
+func wireThing(kind string, data any) map[string]any {
+	return map[string]any{"kind": kind, "data": data}
+}
+
+func wireListing(children []any) map[string]any {
+	return wireThing("Listing", map[string]any{"children": children, "after": nil})
+}
+
+func wirePostListing(posts []*reddit.Post) map[string]any {
+	children := make([]any, len(posts))
+	for i, post := range posts {
+		children[i] = wireThing("t3", wirePost(post))
+	}
+	return wireListing(children)
+}
+
+// wirePostAndComments encodes a post and its comments as the pair of
+// listings returned by /comments/{id}.
+func wirePostAndComments(pc *reddit.PostAndComments) []any {
+	comments := make([]any, len(pc.Comments))
+	for i, comment := range pc.Comments {
+		comments[i] = wireThing("t1", wireComment(comment))
+	}
+	if pc.More != nil {
+		comments = append(comments, wireThing("more", wireMore(pc.More)))
+	}
+	return []any{
+		wireListing([]any{wireThing("t3", wirePost(pc.Post))}),
+		wireListing(comments),
+	}
+}
+
+// wireTime encodes a timestamp the way Reddit does: Unix seconds, or
+// false if unset.
+func wireTime(t *reddit.Timestamp) any {
+	if t == nil {
+		return false
+	}
+	return float64(t.Unix())
+}
+
+func wirePost(post *reddit.Post) map[string]any {
+	return map[string]any{
+		"id":           post.ID,
+		"name":         post.FullID,
+		"title":        post.Title,
+		"selftext":     post.Body,
+		"url":          post.URL,
+		"permalink":    post.Permalink,
+		"subreddit":    post.SubredditName,
+		"author":       post.Author,
+		"created_utc":  wireTime(post.Created),
+		"edited":       wireTime(post.Edited),
+		"score":        post.Score,
+		"locked":       post.Locked,
+		"num_comments": post.NumberOfComments,
+		"is_self":      true,
+	}
+}
+
+// wireComment encodes a comment with its replies. Reddit sends an empty
+// string rather than an empty listing for a comment without replies.
+func wireComment(comment *reddit.Comment) map[string]any {
+	var replies any = ""
+	if len(comment.Replies.Comments) > 0 || comment.Replies.More != nil {
+		children := make([]any, len(comment.Replies.Comments))
+		for i, reply := range comment.Replies.Comments {
+			children[i] = wireThing("t1", wireComment(reply))
+		}
+		if comment.Replies.More != nil {
+			children = append(children, wireThing("more", wireMore(comment.Replies.More)))
+		}
+		replies = wireListing(children)
+	}
+
+	return map[string]any{
+		"id":          comment.ID,
+		"name":        comment.FullID,
+		"parent_id":   comment.ParentID,
+		"link_id":     comment.PostID,
+		"body":        comment.Body,
+		"author":      comment.Author,
+		"permalink":   comment.Permalink,
+		"created_utc": wireTime(comment.Created),
+		"edited":      wireTime(comment.Edited),
+		"score":       comment.Score,
+		"replies":     replies,
+	}
+}
+
+// wireMore encodes a stub for comments left out of a tree.
+func wireMore(more *reddit.More) map[string]any {
+	return map[string]any{
+		"id":        more.ID,
+		"name":      more.FullID,
+		"parent_id": more.ParentID,
+		"count":     more.Count,
+		"depth":     more.Depth,
+		"children":  more.Children,
+	}
+}
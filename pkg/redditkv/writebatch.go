@@ -0,0 +1,279 @@
+package redditkv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// Batch queues writes to several keys and applies them in order. If one
+// of them fails, the batch undoes the ones before it, so related keys are
+// either all updated or left as they were.
+//
+// Reddit has no transactions: while a batch runs, other clients see its
+// writes one at a time, and a key that is put back gets a new post with
+// the old contents rather than its original post.
+type Batch struct {
+	client *KVClient
+	ops    []batchOp
+}
+
+type batchOp struct {
+	op    string // "set", "append" or "delete"
+	key   string
+	value string
+	path  []int
+}
+
+// BatchStatus says what became of one operation in a batch.
+type BatchStatus string
+
+const (
+	// BatchApplied operations were carried out and are still in effect.
+	BatchApplied BatchStatus = "applied"
+	// BatchUndone operations were carried out, then undone.
+	BatchUndone BatchStatus = "undone"
+	// BatchFailed is the operation that failed. Anything it changed
+	// before failing was undone, unless UndoErr says otherwise.
+	BatchFailed BatchStatus = "failed"
+	// BatchSkipped operations were never attempted.
+	BatchSkipped BatchStatus = "skipped"
+)
+
+// BatchOpResult reports on one operation in a batch.
+type BatchOpResult struct {
+	Op     string
+	Key    string
+	Status BatchStatus
+	// Err is why the operation failed.
+	Err error
+	// UndoErr is why undoing the operation's changes failed. The first
+	// failure is kept.
+	UndoErr error
+}
+
+// BatchReport describes what a committed batch did.
+type BatchReport struct {
+	Ops []BatchOpResult
+	// Journal lists every post and comment the batch created or
+	// deleted, oldest first.
+	Journal []JournalEntry
+}
+
+// JournalEntry records one change a batch made.
+type JournalEntry struct {
+	// Op is the index of the operation that made the change.
+	Op int
+	// Action is "create_post", "create_comment" or "delete_post".
+	Action string
+	// ID is the full ID of the post or comment.
+	ID string
+	// Undone is set once the change has been compensated for.
+	Undone bool
+
+	post    string                  // a created comment's post
+	deleted *reddit.PostAndComments // a deleted post, to restore it
+}
+
+// Batch starts an empty write batch.
+func (c *KVClient) Batch() *Batch {
+	return &Batch{client: c}
+}
+
+// Set queues setting a key to a scalar value.
+func (b *Batch) Set(key, value string) *Batch {
+	b.ops = append(b.ops, batchOp{op: "set", key: key, value: value})
+	return b
+}
+
+// Append queues appending a value to a key's tree.
+func (b *Batch) Append(key, value string, parentPath []int) *Batch {
+	b.ops = append(b.ops, batchOp{op: "append", key: key, value: value, path: parentPath})
+	return b
+}
+
+// Delete queues deleting a key.
+func (b *Batch) Delete(key string) *Batch {
+	b.ops = append(b.ops, batchOp{op: "delete", key: key})
+	return b
+}
+
+// Len returns the number of queued operations.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Commit applies the queued operations in order. If one fails, Commit
+// undoes every change the batch made, newest first, and returns a
+// *BatchError. The report is returned either way.
+func (b *Batch) Commit() (*BatchReport, error) {
+	c := b.client
+	report := &BatchReport{Ops: make([]BatchOpResult, len(b.ops))}
+	for i, op := range b.ops {
+		report.Ops[i] = BatchOpResult{Op: op.op, Key: op.key, Status: BatchSkipped}
+	}
+
+	journal := &journalAPI{RedditAPI: c.api, report: report}
+	recorded := c.withAPI(journal)
+
+	for i, op := range b.ops {
+		journal.op = i
+		err := op.apply(recorded)
+		c.invalidate(op.key)
+		if err == nil {
+			report.Ops[i].Status = BatchApplied
+			continue
+		}
+
+		report.Ops[i].Status = BatchFailed
+		report.Ops[i].Err = err
+		undone := b.undo(report, i)
+		return report, &BatchError{Index: i, Key: op.key, Err: err, Undone: undone}
+	}
+
+	return report, nil
+}
+
+func (op batchOp) apply(c *KVClient) error {
+	switch op.op {
+	case "set":
+		return c.Set(op.key, op.value)
+	case "append":
+		return c.Append(op.key, op.value, op.path)
+	case "delete":
+		return c.Delete(op.key)
+	}
+	return fmt.Errorf("unknown batch operation: %s", op.op)
+}
+
+// undo compensates for the journal's changes, newest first, and marks
+// the operations before failed as undone where that worked. It reports
+// whether everything was undone.
+func (b *Batch) undo(report *BatchReport, failed int) bool {
+	c := b.client
+
+	created := make(map[string]*JournalEntry)
+	deleted := make(map[string]bool)
+	for i := range report.Journal {
+		switch e := &report.Journal[i]; e.Action {
+		case "create_post":
+			created[e.ID] = e
+		case "delete_post":
+			deleted[e.ID] = true
+		}
+	}
+
+	for i := len(report.Journal) - 1; i >= 0; i-- {
+		e := &report.Journal[i]
+		var err error
+		switch e.Action {
+		case "create_comment":
+			// Comments go away with their post if the batch created it
+			if created[e.post] != nil {
+				continue
+			}
+			err = c.api.DeleteComment(c.ctx, e.ID)
+		case "create_post":
+			// A later operation in the batch may have deleted it already
+			if !deleted[e.ID] {
+				err = c.api.DeletePost(c.ctx, strings.TrimPrefix(e.ID, "t3_"))
+			}
+		case "delete_post":
+			// Posts the batch created itself stay deleted
+			if created[e.ID] == nil {
+				err = c.restore(e.deleted)
+			}
+		}
+		c.invalidate(b.ops[e.Op].key)
+
+		if err != nil {
+			if report.Ops[e.Op].UndoErr == nil {
+				report.Ops[e.Op].UndoErr = err
+			}
+			continue
+		}
+		e.Undone = true
+	}
+
+	complete := true
+	for i := range report.Journal {
+		e := &report.Journal[i]
+		if e.Action == "create_comment" && created[e.post] != nil {
+			e.Undone = created[e.post].Undone
+		}
+		complete = complete && e.Undone
+	}
+
+	for i := range failed {
+		if report.Ops[i].UndoErr == nil {
+			report.Ops[i].Status = BatchUndone
+		}
+	}
+	return complete
+}
+
+// restore reposts a deleted post with its comment tree.
+func (c *KVClient) restore(old *reddit.PostAndComments) error {
+	submitted, err := c.api.SubmitPost(c.ctx, c.subreddit, old.Post.Title, old.Post.Body)
+	if err != nil {
+		return fmt.Errorf("failed to restore post: %w", err)
+	}
+	return c.copyComments(submitted.FullID, old.Comments)
+}
+
+// withAPI returns a client with c's settings that calls api instead. It
+// has no cache; callers invalidate c's cache themselves.
+func (c *KVClient) withAPI(api RedditAPI) *KVClient {
+	return &KVClient{
+		api:         api,
+		subreddit:   c.subreddit,
+		ctx:         c.ctx,
+		autoMigrate: c.autoMigrate,
+		settleDelay: c.settleDelay,
+		concurrency: c.concurrency,
+	}
+}
+
+// journalAPI writes the posts and comments created and deleted through
+// it to a batch's journal. Before deleting a post it fetches the post, so
+// the deletion can be undone.
+type journalAPI struct {
+	RedditAPI
+	report *BatchReport
+	op     int
+}
+
+func (j *journalAPI) add(e JournalEntry) {
+	e.Op = j.op
+	j.report.Journal = append(j.report.Journal, e)
+}
+
+func (j *journalAPI) SubmitPost(ctx context.Context, subreddit, title, text string) (*reddit.Submitted, error) {
+	submitted, err := j.RedditAPI.SubmitPost(ctx, subreddit, title, text)
+	if err == nil {
+		j.add(JournalEntry{Action: "create_post", ID: submitted.FullID})
+	}
+	return submitted, err
+}
+
+func (j *journalAPI) DeletePost(ctx context.Context, postID string) error {
+	old, err := j.RedditAPI.GetPost(ctx, postID)
+	if err != nil {
+		return fmt.Errorf("failed to save post before deleting it: %w", err)
+	}
+	if err := j.RedditAPI.DeletePost(ctx, postID); err != nil {
+		return err
+	}
+	j.add(JournalEntry{Action: "delete_post", ID: "t3_" + postID, deleted: old})
+	return nil
+}
+
+func (j *journalAPI) SubmitComment(ctx context.Context, parentID, text string) (*reddit.Comment, error) {
+	comment, err := j.RedditAPI.SubmitComment(ctx, parentID, text)
+	if err == nil {
+		j.add(JournalEntry{Action: "create_comment", ID: comment.FullID, post: comment.PostID})
+	}
+	return comment, err
+}
@@ -0,0 +1,148 @@
+package redditkv
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBatchCommit(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit")
+	_ = client.Set("old", "gone soon")
+
+	report, err := client.Batch().
+		Set("a", "1").
+		Set("b", "2").
+		Append("a", "child", []int{0}).
+		Delete("old").
+		Commit()
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	for i, op := range report.Ops {
+		if op.Status != BatchApplied {
+			t.Errorf("Expected operation %d to be applied, got %s", i, op.Status)
+		}
+	}
+	if value, _ := client.Get("a"); len(value.Children) != 1 {
+		t.Errorf("Expected the appended child, got %+v", value)
+	}
+	if exists, _ := client.Exists("old"); exists {
+		t.Error("Expected 'old' to be deleted")
+	}
+
+	// Two posts, three comments and one deletion
+	if len(report.Journal) != 6 {
+		t.Errorf("Expected 6 journal entries, got %+v", report.Journal)
+	}
+}
+
+func TestBatchUndo(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+	_ = client.Set("existing", "before")
+	_ = client.Append("existing", "child", []int{0})
+	_ = client.Set("doomed", "still here")
+
+	report, err := client.Batch().
+		Set("existing", "after").
+		Set("new", "value").
+		Delete("doomed").
+		Append("missing", "value", nil).
+		Set("never", "value").
+		Commit()
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Expected BatchError, got %v", err)
+	}
+	if batchErr.Index != 3 || !batchErr.Undone {
+		t.Errorf("Unexpected error: %+v", batchErr)
+	}
+	var notFound *KeyNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("Expected the cause to be KeyNotFoundError, got %v", err)
+	}
+
+	want := []BatchStatus{BatchUndone, BatchUndone, BatchUndone, BatchFailed, BatchSkipped}
+	for i, status := range want {
+		if report.Ops[i].Status != status {
+			t.Errorf("Expected operation %d to be %s, got %s", i, status, report.Ops[i].Status)
+		}
+	}
+	for _, e := range report.Journal {
+		if !e.Undone {
+			t.Errorf("Expected %s %s to be undone", e.Action, e.ID)
+		}
+	}
+
+	// The store is back to how it was
+	value, err := client.Get("existing")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "before" || len(value.Children) != 1 || value.Children[0].Value != "child" {
+		t.Errorf("Expected the original tree back, got %+v", value)
+	}
+	if value, _ := client.Get("doomed"); value == nil || value.Value != "still here" {
+		t.Errorf("Expected 'doomed' to be restored, got %+v", value)
+	}
+	if exists, _ := client.Exists("new"); exists {
+		t.Error("Expected 'new' to be removed")
+	}
+	if n := mock.GetPostCount(); n != 2 {
+		t.Errorf("Expected 2 posts, got %d", n)
+	}
+}
+
+func TestBatchUndoOverwrittenInBatch(t *testing.T) {
+	mock := NewMockRedditAPI()
+	client := NewWithAPI(mock, "testsubreddit")
+
+	_, err := client.Batch().
+		Set("a", "1").
+		Set("a", "2").
+		Append("missing", "value", nil).
+		Commit()
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Expected BatchError, got %v", err)
+	}
+	if !batchErr.Undone {
+		t.Errorf("Expected the batch to be undone, got %+v", batchErr)
+	}
+	if exists, _ := client.Exists("a"); exists {
+		t.Error("Expected 'a' not to be brought back")
+	}
+	if n := mock.GetPostCount(); n != 0 {
+		t.Errorf("Expected no posts, got %d", n)
+	}
+}
+
+func TestBatchUndoFailure(t *testing.T) {
+	mock := NewMockRedditAPI()
+	broken, _ := failing("DeletePost", 1, errors.New("delete failed"))
+	client := NewWithAPI(mock, "testsubreddit", WithMiddleware(broken))
+
+	report, err := client.Batch().
+		Set("new", "value").
+		Delete("missing").
+		Commit()
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Expected BatchError, got %v", err)
+	}
+	if batchErr.Undone {
+		t.Error("Expected the batch to be reported as partly applied")
+	}
+
+	op := report.Ops[0]
+	if op.Status != BatchApplied || op.UndoErr == nil {
+		t.Errorf("Expected the Set to remain applied with an undo error, got %+v", op)
+	}
+	if exists, _ := client.Exists("new"); !exists {
+		t.Error("Expected 'new' to still exist")
+	}
+}
@@ -0,0 +1,523 @@
+package redditkv
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// ConflictPolicy decides what Flush does with a queued write to a key
+// that was overwritten on Reddit after the write was queued. Reddit only
+// tells us when a key's post was created, so overwrites by Set are
+// detected; appends by other clients are not conflicts.
+type ConflictPolicy string
+
+const (
+	// LocalWins applies queued writes as if they were made at flush
+	// time, replacing whatever is on Reddit. This is the default.
+	LocalWins ConflictPolicy = "local-wins"
+	// RemoteWins drops a queued write if the key's post was created
+	// after the write was queued, keeping the newer remote value.
+	RemoteWins ConflictPolicy = "remote-wins"
+)
+
+// Defaults for WriteBehindConfig.
+const (
+	defaultFlushAttempts = 3
+	defaultFlushBackoff  = time.Second
+)
+
+// WriteBehindConfig configures a WriteBehind client.
+type WriteBehindConfig struct {
+	// Path is the queue file.
+	Path string
+	// Policy is the conflict policy; empty means LocalWins.
+	Policy ConflictPolicy
+	// Attempts is how often Flush tries each write before giving up
+	// until the next flush.
+	Attempts int
+	// Backoff is the wait after the first failed attempt; it doubles
+	// with each further attempt.
+	Backoff time.Duration
+}
+
+// QueuedWrite is a write waiting in the queue file.
+type QueuedWrite struct {
+	Seq      uint64    `json:"seq"`
+	Op       string    `json:"op"` // "set", "append" or "delete"
+	Key      string    `json:"key"`
+	Value    string    `json:"value,omitempty"`
+	Path     []int     `json:"path,omitempty"`
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// FailedWrite is a queued write that Reddit rejected for good.
+type FailedWrite struct {
+	QueuedWrite
+	Err error
+}
+
+// FlushReport describes what a flush did.
+type FlushReport struct {
+	// Applied is the number of writes made on Reddit.
+	Applied int
+	// Conflicts were dropped under the RemoteWins policy.
+	Conflicts []QueuedWrite
+	// Failed were dropped because Reddit rejected them, e.g. an append
+	// to a key that doesn't exist.
+	Failed []FailedWrite
+	// Remaining is the number of writes still queued.
+	Remaining int
+}
+
+// WriteBehind is a Client whose writes go to a local queue file and are
+// acknowledged at once, so they succeed even while Reddit is unreachable.
+// Flush applies them to Reddit later, in order. Reads see the queued
+// writes on top of what's on Reddit; a key whose latest queued write is a
+// Set or Delete is answered without asking Reddit at all.
+//
+// Several processes may share a queue file. Pending appends show up as
+// the last child of the node they target until they are flushed.
+type WriteBehind struct {
+	client *KVClient
+	cfg    WriteBehindConfig
+}
+
+// NewWriteBehind creates a write-behind client that flushes to c.
+func NewWriteBehind(c *KVClient, cfg WriteBehindConfig) *WriteBehind {
+	if cfg.Policy == "" {
+		cfg.Policy = LocalWins
+	}
+	if cfg.Attempts <= 0 {
+		cfg.Attempts = defaultFlushAttempts
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = defaultFlushBackoff
+	}
+	return &WriteBehind{client: c, cfg: cfg}
+}
+
+// QueuePath returns the default queue file for a configuration. It lives
+// next to the config file rather than in the cache directory, since it
+// holds writes that exist nowhere else.
+func QueuePath(cfg Config) (string, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "queue-"+configHash(cfg)+".jsonl"), nil
+}
+
+// Set queues setting a key to a scalar value.
+func (w *WriteBehind) Set(key, value string) error {
+	return w.enqueue(QueuedWrite{Op: "set", Key: key, Value: value})
+}
+
+// Append queues appending a value to a key's tree.
+func (w *WriteBehind) Append(key, value string, parentPath []int) error {
+	return w.enqueue(QueuedWrite{Op: "append", Key: key, Value: value, Path: parentPath})
+}
+
+// Delete queues deleting a key.
+func (w *WriteBehind) Delete(key string) error {
+	return w.enqueue(QueuedWrite{Op: "delete", Key: key})
+}
+
+// Get returns the key's value with its queued writes applied.
+func (w *WriteBehind) Get(key string) (*ValueNode, error) {
+	pending, err := w.pendingFor(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// The newest Set or Delete decides the base value
+	var value *ValueNode
+	start := 0
+	for i, qw := range pending {
+		switch qw.Op {
+		case "set":
+			value, start = &ValueNode{Value: qw.Value, Children: []ValueNode{}}, i+1
+		case "delete":
+			value, start = nil, i+1
+		}
+	}
+
+	topLevel := 1
+	switch {
+	case start == 0 && len(pending) == 0:
+		value, err = w.client.Get(key)
+	case start == 0:
+		value, topLevel, err = w.fetch(key)
+	}
+	var notFound *KeyNotFoundError
+	if err != nil && !errors.As(err, &notFound) {
+		return nil, err
+	}
+
+	for _, qw := range pending[start:] {
+		if value == nil {
+			continue // flushing this append will fail
+		}
+		node := ValueNode{Value: qw.Value, Children: []ValueNode{}}
+		if len(qw.Path) == 0 {
+			// A new top-level comment follows the others, ahead of the
+			// first comment's replies
+			value.Children = slices.Insert(value.Children, topLevel-1, node)
+			topLevel++
+			continue
+		}
+		if parent := pendingParent(value, topLevel, qw.Path); parent != nil {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	if value == nil {
+		return nil, &KeyNotFoundError{Key: key}
+	}
+	// Queued writes have no version yet
+	if len(pending) > 0 {
+		value.Version = ""
+	}
+	return value, nil
+}
+
+// fetch reads a key from Reddit along with its number of top-level
+// comments, which decides where pending appends show up in the tree.
+func (w *WriteBehind) fetch(key string) (*ValueNode, int, error) {
+	c := w.client
+	post, err := c.findPostByTitle(key)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find key: %w", err)
+	}
+	if post == nil {
+		return nil, 0, &KeyNotFoundError{Key: key}
+	}
+	postAndComments, err := c.api.GetPost(c.ctx, post.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get post: %w", err)
+	}
+	value, err := valueFromPost(key, postAndComments)
+	return value, len(postAndComments.Comments), err
+}
+
+// pendingParent finds the node an append with path would go under, in
+// the tree shape Get returns for a key with topLevel top-level comments:
+// path [0] is the root, the other top-level comments are the root's
+// first children, and the first comment's replies come after them.
+func pendingParent(root *ValueNode, topLevel int, path []int) *ValueNode {
+	var node *ValueNode
+	switch {
+	case path[0] == 0 && len(path) == 1:
+		return root
+	case path[0] == 0:
+		i := topLevel - 1 + path[1]
+		if path[1] < 0 || i >= len(root.Children) {
+			return nil
+		}
+		node, path = &root.Children[i], path[2:]
+	case path[0] > 0 && path[0] < topLevel:
+		node, path = &root.Children[path[0]-1], path[1:]
+	default:
+		return nil
+	}
+	for _, i := range path {
+		if i < 0 || i >= len(node.Children) {
+			return nil
+		}
+		node = &node.Children[i]
+	}
+	return node
+}
+
+// Exists checks if a key exists, counting queued writes.
+func (w *WriteBehind) Exists(key string) (bool, error) {
+	pending, err := w.pendingFor(key)
+	if err != nil {
+		return false, err
+	}
+	for _, qw := range slices.Backward(pending) {
+		switch qw.Op {
+		case "set":
+			return true, nil
+		case "delete":
+			return false, nil
+		}
+	}
+	return w.client.Exists(key)
+}
+
+// Keys returns the keys on Reddit with queued Sets and Deletes applied.
+func (w *WriteBehind) Keys() ([]string, error) {
+	queue, err := w.Pending()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := w.client.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make(map[string]bool)
+	listed := make(map[string]bool)
+	for _, key := range keys {
+		exists[key], listed[key] = true, true
+	}
+	for _, qw := range queue {
+		switch qw.Op {
+		case "set":
+			if !listed[qw.Key] {
+				keys = append(keys, qw.Key)
+				listed[qw.Key] = true
+			}
+			exists[qw.Key] = true
+		case "delete":
+			exists[qw.Key] = false
+		}
+	}
+	return slices.DeleteFunc(keys, func(key string) bool { return !exists[key] }), nil
+}
+
+// Pending returns the queued writes, oldest first.
+func (w *WriteBehind) Pending() ([]QueuedWrite, error) {
+	var queue []QueuedWrite
+	err := w.withQueue(func(q []QueuedWrite) ([]QueuedWrite, bool) {
+		queue = q
+		return q, false
+	})
+	return queue, err
+}
+
+func (w *WriteBehind) pendingFor(key string) ([]QueuedWrite, error) {
+	queue, err := w.Pending()
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(queue, func(qw QueuedWrite) bool { return qw.Key != key }), nil
+}
+
+// Flush applies the queued writes to Reddit in order, removing each one
+// from the queue once it's done. A write that keeps failing with what
+// looks like a temporary error stops the flush and stays queued, along
+// with everything after it, and its error is returned.
+func (w *WriteBehind) Flush() (*FlushReport, error) {
+	// One flush at a time, or writes would be applied twice
+	unlock, err := w.lock(".flush.lock")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	queue, err := w.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &FlushReport{}
+	written := make(map[string]bool) // keys this flush has overwritten
+	for i, qw := range queue {
+		conflict, err := w.apply(qw, written)
+		switch {
+		case conflict:
+			report.Conflicts = append(report.Conflicts, qw)
+		case err == nil:
+			report.Applied++
+		case isPermanent(err):
+			report.Failed = append(report.Failed, FailedWrite{QueuedWrite: qw, Err: err})
+		default:
+			report.Remaining = len(queue) - i
+			return report, fmt.Errorf("failed to flush %s of key %s: %w", qw.Op, qw.Key, err)
+		}
+
+		if err := w.remove(qw.Seq); err != nil {
+			report.Remaining = len(queue) - i
+			return report, err
+		}
+	}
+
+	queue, err = w.Pending()
+	if err == nil {
+		report.Remaining = len(queue)
+	}
+	return report, err
+}
+
+// FlushLoop flushes every interval until ctx is done, passing each
+// flush's outcome to report if it isn't nil.
+func (w *WriteBehind) FlushLoop(ctx context.Context, interval time.Duration, report func(*FlushReport, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r, err := w.Flush()
+		if report != nil {
+			report(r, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// apply makes one queued write on Reddit, retrying temporary errors. It
+// reports a conflict instead if the policy says to drop the write.
+func (w *WriteBehind) apply(qw QueuedWrite, written map[string]bool) (conflict bool, err error) {
+	if w.cfg.Policy == RemoteWins && !written[qw.Key] {
+		post, err := w.client.findPostByTitle(qw.Key)
+		if err != nil {
+			return false, err
+		}
+		if post != nil && post.Created != nil && post.Created.After(qw.QueuedAt) {
+			return true, nil
+		}
+	}
+
+	wait := w.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		switch qw.Op {
+		case "set":
+			err = w.client.Set(qw.Key, qw.Value)
+			written[qw.Key] = true
+		case "append":
+			err = w.client.Append(qw.Key, qw.Value, qw.Path)
+		case "delete":
+			err = w.client.Delete(qw.Key)
+			// Already gone is as good as deleted
+			var notFound *KeyNotFoundError
+			if errors.As(err, &notFound) {
+				err = nil
+			}
+			written[qw.Key] = true
+		default:
+			return false, fmt.Errorf("unknown queued operation: %s", qw.Op)
+		}
+
+		if err == nil || attempt >= w.cfg.Attempts || isPermanent(err) {
+			return false, err
+		}
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// isPermanent reports whether a write failed for a reason that retrying
+// won't fix.
+func isPermanent(err error) bool {
+	var (
+		notFound *KeyNotFoundError
+		badPath  *InvalidPathError
+		archived *ArchivedKeyError
+		locked   *LockedKeyError
+		removed  *RemovedValueError
+	)
+	return errors.As(err, &notFound) || errors.As(err, &badPath) ||
+		errors.As(err, &archived) || errors.As(err, &locked) || errors.As(err, &removed)
+}
+
+// enqueue appends a write to the queue file and syncs it to disk.
+func (w *WriteBehind) enqueue(qw QueuedWrite) error {
+	return w.withQueue(func(q []QueuedWrite) ([]QueuedWrite, bool) {
+		qw.QueuedAt = time.Now()
+		if len(q) > 0 {
+			qw.Seq = q[len(q)-1].Seq + 1
+		} else {
+			qw.Seq = 1
+		}
+		return append(q, qw), true
+	})
+}
+
+// remove deletes a flushed write from the queue file.
+func (w *WriteBehind) remove(seq uint64) error {
+	return w.withQueue(func(q []QueuedWrite) ([]QueuedWrite, bool) {
+		return slices.DeleteFunc(q, func(qw QueuedWrite) bool { return qw.Seq == seq }), true
+	})
+}
+
+// withQueue loads the queue file under its lock, runs fn, and writes
+// the queue back if fn reports a change.
+func (w *WriteBehind) withQueue(fn func(q []QueuedWrite) ([]QueuedWrite, bool)) error {
+	unlock, err := w.lock(".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(w.cfg.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read write queue: %w", err)
+	}
+
+	var queue []QueuedWrite
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var qw QueuedWrite
+		if err := json.Unmarshal(scanner.Bytes(), &qw); err != nil {
+			return fmt.Errorf("failed to parse write queue: %w", err)
+		}
+		queue = append(queue, qw)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read write queue: %w", err)
+	}
+
+	queue, changed := fn(queue)
+	if !changed {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, qw := range queue {
+		if err := enc.Encode(qw); err != nil {
+			return err
+		}
+	}
+	return writeFileSync(w.cfg.Path, buf.Bytes())
+}
+
+func (w *WriteBehind) lock(suffix string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(w.cfg.Path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+	unlock, err := lockFile(w.cfg.Path + suffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock write queue: %w", err)
+	}
+	return unlock, nil
+}
+
+// writeFileSync replaces a file's contents, syncing them to disk before
+// the rename so a crash leaves either the old or the new file.
+func writeFileSync(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
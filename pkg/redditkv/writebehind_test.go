@@ -0,0 +1,207 @@
+package redditkv
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// outage returns a middleware that fails every call while down is set.
+func outage(down *atomic.Bool) Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (any, error) {
+			if down.Load() {
+				return nil, errors.New("connection refused")
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+func newWriteBehind(t *testing.T, client *KVClient, policy ConflictPolicy) *WriteBehind {
+	t.Helper()
+	return NewWriteBehind(client, WriteBehindConfig{
+		Path:     filepath.Join(t.TempDir(), "queue.jsonl"),
+		Policy:   policy,
+		Attempts: 2,
+		Backoff:  time.Millisecond,
+	})
+}
+
+func TestWriteBehindOffline(t *testing.T) {
+	mock := NewMockRedditAPI()
+	var down atomic.Bool
+	down.Store(true)
+	wb := newWriteBehind(t, NewWithAPI(mock, "testsubreddit", WithMiddleware(outage(&down))), "")
+
+	if err := wb.Set("mykey", "root"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := wb.Append("mykey", "child", []int{0}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	_ = wb.Set("other", "value")
+	_ = wb.Delete("other")
+
+	// Reads see the queued writes without reaching Reddit
+	value, err := wb.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "root" || len(value.Children) != 1 || value.Children[0].Value != "child" {
+		t.Errorf("Expected the queued tree, got %+v", value)
+	}
+	if exists, _ := wb.Exists("other"); exists {
+		t.Error("Expected 'other' to be deleted")
+	}
+
+	// A flush during the outage keeps everything queued
+	report, err := wb.Flush()
+	if err == nil {
+		t.Fatal("Expected Flush to fail during the outage")
+	}
+	if report.Remaining != 4 {
+		t.Errorf("Expected 4 writes to remain, got %d", report.Remaining)
+	}
+
+	down.Store(false)
+	report, err = wb.Flush()
+	if err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if report.Applied != 4 || report.Remaining != 0 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	value, err = NewWithAPI(mock, "testsubreddit").Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value.Value != "root" || len(value.Children) != 1 {
+		t.Errorf("Expected the flushed tree, got %+v", value)
+	}
+	if mock.GetPostCount() != 1 {
+		t.Errorf("Expected only 'mykey' on Reddit, got %d posts", mock.GetPostCount())
+	}
+}
+
+func TestWriteBehindOverlay(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit")
+	_ = client.Set("mykey", "root")
+	_ = client.Set("gone", "value")
+	wb := newWriteBehind(t, client, "")
+
+	_ = wb.Append("mykey", "pending", nil)
+	_ = wb.Delete("gone")
+	_ = wb.Set("new", "value")
+
+	value, err := wb.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(value.Children) != 1 || value.Children[0].Value != "pending" {
+		t.Errorf("Expected the pending append, got %+v", value)
+	}
+	if value.Version != "" {
+		t.Errorf("Expected no version with pending writes, got %s", value.Version)
+	}
+
+	keys, err := wb.Keys()
+	if err != nil {
+		t.Fatalf("Keys failed: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("Expected 'mykey' and 'new', got %v", keys)
+	}
+}
+
+func TestWriteBehindOverlayTopLevelComments(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit")
+	_ = client.Set("mykey", "root")
+	_ = client.Append("mykey", "second", nil)
+	_ = client.Append("mykey", "third", nil)
+	_ = client.Append("mykey", "reply", []int{0})
+	wb := newWriteBehind(t, client, "")
+
+	// The root's reply comes after the other top-level comments in Get's
+	// tree, so these must not land under "second"
+	_ = wb.Append("mykey", "nested", []int{0, 0})
+	_ = wb.Append("mykey", "fourth", nil)
+	_ = wb.Append("mykey", "under-third", []int{2})
+
+	pending, err := wb.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if _, err := wb.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	flushed, err := client.Get("mykey")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	flushed.Version = ""
+
+	if !reflect.DeepEqual(pending, flushed) {
+		t.Errorf("Pending tree differs from the flushed one:\npending: %+v\nflushed: %+v", pending, flushed)
+	}
+}
+
+func TestWriteBehindConflictPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy ConflictPolicy
+		want   string
+	}{
+		{LocalWins, "queued"},
+		{RemoteWins, "remote"},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			mock := NewMockRedditAPI()
+			wb := newWriteBehind(t, NewWithAPI(mock, "testsubreddit"), tc.policy)
+			_ = wb.Set("mykey", "queued")
+
+			// Someone else overwrites the key before the flush
+			time.Sleep(time.Millisecond)
+			_ = NewWithAPI(mock, "testsubreddit").Set("mykey", "remote")
+
+			report, err := wb.Flush()
+			if err != nil {
+				t.Fatalf("Flush failed: %v", err)
+			}
+			if tc.policy == RemoteWins && len(report.Conflicts) != 1 {
+				t.Errorf("Expected a conflict, got %+v", report)
+			}
+
+			value, _ := NewWithAPI(mock, "testsubreddit").Get("mykey")
+			if value.Value != tc.want {
+				t.Errorf("Expected '%s', got '%s'", tc.want, value.Value)
+			}
+		})
+	}
+}
+
+func TestWriteBehindRejectedWrite(t *testing.T) {
+	client := NewWithAPI(NewMockRedditAPI(), "testsubreddit")
+	wb := newWriteBehind(t, client, "")
+
+	_ = wb.Append("missing", "value", nil)
+	_ = wb.Set("mykey", "root")
+
+	report, err := wb.Flush()
+	if err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if len(report.Failed) != 1 || report.Failed[0].Key != "missing" {
+		t.Errorf("Expected the append to be rejected, got %+v", report.Failed)
+	}
+	if report.Applied != 1 || report.Remaining != 0 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if exists, _ := client.Exists("mykey"); !exists {
+		t.Error("Expected the later write to be applied")
+	}
+}